@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// archiveDirName is the folder in the todo directory finished notes are
+// moved to. Archived notes keep their relative path inside it.
+const archiveDirName = "archive"
+
+// archiveItem is an archived note, named by its original path.
+type archiveItem struct {
+	filename string
+	archived time.Time
+}
+
+func (i archiveItem) Title() string { return i.filename }
+func (i archiveItem) Description() string {
+	return "Archived: " + i.archived.Format("Jan 02, 2006 3:04 PM")
+}
+func (i archiveItem) FilterValue() string { return i.filename }
+
+type archiveKeyMap struct {
+	restore key.Binding
+	back    key.Binding
+}
+
+func newArchiveKeyMap() *archiveKeyMap {
+	return &archiveKeyMap{
+		restore: key.NewBinding(
+			key.WithKeys("r", "enter"),
+			key.WithHelp("r", "restore"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+}
+
+func (m *model) archiveDir() string {
+	return filepath.Join(m.todoDir, archiveDirName)
+}
+
+// archiveTodo moves filename, relative to the todo directory, into the
+// archive. An archived note of the same name is never overwritten.
+func (m *model) archiveTodo(filename string) (err error) {
+	defer func() { logOp("archive", filename, err) }()
+	target := filepath.Join(m.archiveDir(), filename)
+	if _, err := os.Stat(target); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(m.todoDir, filename), target); err != nil {
+		return err
+	}
+	m.index.forget(filename)
+	m.saveIndex()
+	return nil
+}
+
+// loadArchive lists the archived notes, most recently archived first.
+func (m *model) loadArchive() []list.Item {
+	items := []list.Item{}
+	filepath.WalkDir(m.archiveDir(), func(path string, file fs.DirEntry, err error) error {
+		if err != nil || file.IsDir() || !isNoteFile(file.Name()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(m.archiveDir(), path)
+		if err != nil {
+			return nil
+		}
+		it := archiveItem{filename: filepath.ToSlash(relPath)}
+		if info, err := file.Info(); err == nil {
+			it.archived = info.ModTime()
+		}
+		items = append(items, it)
+		return nil
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].(archiveItem).archived.After(items[j].(archiveItem).archived)
+	})
+	return items
+}
+
+// restoreFromArchive moves an archived note back into the todo list,
+// refusing to overwrite a note that has since taken its place.
+func (m *model) restoreFromArchive(it archiveItem) (err error) {
+	defer func() { logOp("restore from archive", it.filename, err) }()
+	target := filepath.Join(m.todoDir, it.filename)
+	if _, err := os.Stat(target); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(m.archiveDir(), it.filename), target)
+}
+
+// openArchive loads the archive into a fresh list and switches to it.
+func (m *model) openArchive() {
+	m.archiveList = list.New(m.loadArchive(), list.NewDefaultDelegate(), 0, 0)
+	m.archiveList.Title = "Archive"
+	m.archiveList.Styles.Title = todoTitleStyle
+	keys := m.archiveKeys
+	m.archiveList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.restore}
+	}
+
+	h, v := docStyle.GetFrameSize()
+	m.archiveList.SetSize(m.width-h, m.height-v)
+
+	m.state = archiveView
+}
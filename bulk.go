@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bulkAction is what happens to the marked todos once confirmed.
+type bulkAction int
+
+const (
+	bulkTrash bulkAction = iota
+	bulkArchive
+)
+
+// toggleMark marks or unmarks filename for a bulk action.
+func (m *model) toggleMark(filename string) {
+	if m.marked[filename] {
+		delete(m.marked, filename)
+		return
+	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	m.marked[filename] = true
+}
+
+// markedFiles returns the marked filenames in a stable order.
+func (m *model) markedFiles() []string {
+	files := make([]string, 0, len(m.marked))
+	for filename := range m.marked {
+		files = append(files, filename)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// runBulkAction applies the pending bulk action to every marked todo and
+// clears the marks. It carries on past failures and returns a status
+// message summing up the batch, along with a command committing it.
+func (m *model) runBulkAction() (string, tea.Cmd) {
+	files := m.markedFiles()
+	m.marked = nil
+
+	done := 0
+	var changed []string
+	var firstErr error
+	for _, filename := range files {
+		var err error
+		if m.bulkAction == bulkArchive {
+			err = m.archiveTodo(filename)
+		} else {
+			_, err = m.moveToTrash(filename)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", filename, err)
+			}
+			continue
+		}
+		delete(m.pinned, filename)
+		changed = append(changed, filename)
+		if m.bulkAction == bulkArchive {
+			changed = append(changed, path.Join(archiveDirName, filename))
+		}
+		done++
+	}
+
+	verb := "Moved %d file(s) to the trash"
+	if m.bulkAction == bulkArchive {
+		verb = "Archived %d file(s)"
+	}
+	status := fmt.Sprintf(verb, done)
+	if firstErr != nil {
+		status += fmt.Sprintf(", %d failed (%v)", len(files)-done, firstErr)
+	}
+
+	var commitCmd tea.Cmd
+	if done > 0 {
+		commitCmd = m.gitCommit(strings.ToLower(fmt.Sprintf(verb, done)), changed...)
+	}
+	return status, commitCmd
+}
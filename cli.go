@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// appendTask adds text as an unchecked task at the end of filename in
+// dir, creating the file (and any subfolders) if needed, and returns the
+// file's name with its extension.
+func appendTask(dir, filename, text string) (string, error) {
+	filename, err := todoFileName(filename, noteExtensions[0])
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, filename)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	line := "- [ ] " + strings.TrimSpace(text) + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		line = "\n" + line
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(line); err != nil {
+		f.Close()
+		return "", err
+	}
+	return filename, f.Close()
+}
+
+// createNote saves content as a new note called filename in dir, or one
+// named after today's date when filename is empty, and returns the
+// note's name. An existing note is never overwritten: a named note fails
+// instead, and a dated one gets a numbered name.
+func createNote(dir, filename, content string, cipher *noteCipher) (string, error) {
+	dated := filename == ""
+	if dated {
+		filename = time.Now().Format("2006-01-02")
+	}
+	filename, err := todoFileName(filename, noteExtensions[0])
+	if err != nil {
+		return "", err
+	}
+	if isMarkdown(filename) {
+		content = stampFrontmatter(content, time.Now(), true)
+	}
+	data := []byte(content)
+	base, ext := noteName(filename), noteExt(filename)
+	if cipher != nil {
+		if data, err = cipher.encrypt(data); err != nil {
+			return "", err
+		}
+		ext += encryptedExt
+	}
+	if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(filename)), 0755); err != nil {
+		return "", err
+	}
+
+	name := base + ext
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if dated && errors.Is(err, fs.ErrExist) {
+			name = fmt.Sprintf("%s-%d%s", base, n, ext)
+			continue
+		}
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("%s already exists", name)
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return "", err
+		}
+		return name, f.Close()
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+)
+
+// plainText renders markdown without colours or escape codes, for pasting
+// outside the terminal.
+func plainText(markdown string) (string, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(styles.NoTTYStyle),
+		glamour.WithWordWrap(0),
+	)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := r.Render(stripFrontmatter(markdown))
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(rendered, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n") + "\n", nil
+}
+
+// copyNote puts a note on the system clipboard, as markdown or rendered
+// to plain text, and returns a status message saying how it went.
+func copyNote(name, markdown string, rendered bool) string {
+	text := markdown
+	if rendered {
+		var err error
+		if text, err = plainText(markdown); err != nil {
+			return "Copy failed: " + err.Error()
+		}
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		return "Copy failed: " + err.Error()
+	}
+
+	kind := "markdown"
+	if rendered {
+		kind = "text"
+	}
+	return fmt.Sprintf("Copied %s as %s (%d bytes)", name, kind, len(text))
+}
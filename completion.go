@@ -0,0 +1,45 @@
+package main
+
+import "github.com/charmbracelet/bubbles/list"
+
+// completionFilter narrows the todo list by how far along each note's
+// checklist is.
+type completionFilter int
+
+const (
+	showAllNotes completionFilter = iota
+	showOpenTasks
+	showComplete
+)
+
+func (c completionFilter) String() string {
+	switch c {
+	case showOpenTasks:
+		return "open tasks"
+	case showComplete:
+		return "complete"
+	default:
+		return "all"
+	}
+}
+
+// next cycles through all, open tasks and complete.
+func (c completionFilter) next() completionFilter {
+	return (c + 1) % (showComplete + 1)
+}
+
+// filterByCompletion keeps the todo items matching c. Notes without any
+// checkboxes have nothing open, so they count as complete.
+func filterByCompletion(items []list.Item, c completionFilter) []list.Item {
+	if c == showAllNotes {
+		return items
+	}
+	filtered := []list.Item{}
+	for _, it := range items {
+		todo := it.(todoItem)
+		if (todo.done < todo.total) == (c == showOpenTasks) {
+			filtered = append(filtered, it)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// errChangedOnDisk stops a save that would overwrite changes made to the
+// note by something else since it was opened.
+var errChangedOnDisk = errors.New("note changed on disk since it was opened")
+
+// noteModTime returns when the note open in the editor was last written
+// on disk, or the zero time if it isn't there.
+func (m model) noteModTime() time.Time {
+	info, err := os.Stat(m.currentFilePath())
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// openSaveConflict asks what to do about a note that changed on disk,
+// closing the editor afterwards if the save was meant to.
+func (m *model) openSaveConflict(thenClose bool) {
+	m.conflictClose = thenClose
+	m.editor.Blur()
+	m.state = confirmOverwriteView
+}
+
+// reloadNote replaces the buffer with the note as it is on disk, keeping
+// the cursor where it was as far as the new text goes.
+func (m *model) reloadNote() {
+	content, err := m.readNote(m.currentFileName())
+	if err != nil {
+		m.editorStatus = "Reload failed: " + err.Error()
+		return
+	}
+	// Reloading goes through undo, so the discarded version can be
+	// brought back
+	m.setEditorValue(normalizeNewlines(string(content)))
+	m.dirty = false
+	m.meta = parseMeta(string(content))
+	m.diskModTime = m.noteModTime()
+	m.editorStatus = "Reloaded from disk, " + m.editorKeys.undo.Help().Key + " brings your version back"
+}
+
+// resolveSaveConflict overwrites the note on disk with the buffer, or
+// reloads the buffer from disk, as picked in confirmOverwriteView.
+func (m *model) resolveSaveConflict(overwrite bool) tea.Cmd {
+	m.state = editorView
+	m.editor.Focus()
+	if !overwrite {
+		m.reloadNote()
+		return tea.Batch(textarea.Blink, m.startAutosave())
+	}
+
+	m.diskModTime = m.noteModTime()
+	if m.lastErr = m.saveFile(); m.lastErr != nil {
+		return tea.Batch(textarea.Blink, m.startAutosave())
+	}
+	cmd := m.commitCurrentFile()
+	if m.conflictClose {
+		return tea.Batch(cmd, m.closeEditor())
+	}
+	return tea.Batch(cmd, textarea.Blink, m.startAutosave())
+}
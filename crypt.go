@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+)
+
+// encryptedExt follows ".md" on notes stored encrypted.
+const encryptedExt = ".enc"
+
+// pbkdf2Iterations is the work factor for deriving a key from the
+// passphrase, following current OWASP advice for PBKDF2-SHA256.
+const pbkdf2Iterations = 600_000
+
+// saltSize is the length of the random salt stored with each note.
+const saltSize = 16
+
+// encryptedMagic starts every encrypted note, so other files can't be
+// mistaken for one.
+var encryptedMagic = []byte("gotodo-aes-gcm-v1\n")
+
+var (
+	errNoPassphrase    = errors.New("note is encrypted, set GOTODO_PASSPHRASE to open it")
+	errWrongPassphrase = errors.New("wrong passphrase, or the note is damaged")
+)
+
+// noteCipher encrypts and decrypts notes with a key derived from the
+// user's passphrase. Derivation is deliberately slow, so keys are cached
+// per salt and one salt is used for everything written this session.
+type noteCipher struct {
+	passphrase string
+
+	mu        sync.Mutex
+	keys      map[string][]byte
+	writeSalt []byte
+}
+
+// noteCipherFromEnv returns a cipher for $GOTODO_PASSPHRASE, or nil when
+// it's unset and notes are stored as plain markdown.
+func noteCipherFromEnv() *noteCipher {
+	passphrase := os.Getenv("GOTODO_PASSPHRASE")
+	if passphrase == "" {
+		return nil
+	}
+	return &noteCipher{passphrase: passphrase, keys: make(map[string][]byte)}
+}
+
+// gcm returns the AES-GCM cipher for salt, deriving its key on first use.
+func (c *noteCipher) gcm(salt []byte) (cipher.AEAD, error) {
+	c.mu.Lock()
+	key, ok := c.keys[string(salt)]
+	if !ok {
+		var err error
+		key, err = pbkdf2.Key(sha256.New, c.passphrase, salt, pbkdf2Iterations, 32)
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.keys[string(salt)] = key
+	}
+	c.mu.Unlock()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encrypt seals plain as magic, salt, nonce and ciphertext.
+func (c *noteCipher) encrypt(plain []byte) ([]byte, error) {
+	c.mu.Lock()
+	if c.writeSalt == nil {
+		c.writeSalt = make([]byte, saltSize)
+		if _, err := rand.Read(c.writeSalt); err != nil {
+			c.writeSalt = nil
+			c.mu.Unlock()
+			return nil, err
+		}
+	}
+	salt := c.writeSalt
+	c.mu.Unlock()
+
+	aead, err := c.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(append(append([]byte{}, encryptedMagic...), salt...), nonce...)
+	return aead.Seal(out, nonce, plain, nil), nil
+}
+
+// decrypt opens data written by encrypt. A wrong passphrase fails GCM's
+// authentication rather than producing garbage.
+func (c *noteCipher) decrypt(data []byte) ([]byte, error) {
+	if c == nil {
+		return nil, errNoPassphrase
+	}
+	rest, ok := bytes.CutPrefix(data, encryptedMagic)
+	if !ok || len(rest) < saltSize {
+		return nil, errWrongPassphrase
+	}
+	salt, rest := rest[:saltSize], rest[saltSize:]
+
+	aead, err := c.gcm(salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) < aead.NonceSize() {
+		return nil, errWrongPassphrase
+	}
+	plain, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return plain, nil
+}
+
+// isEncrypted reports whether filename is an encrypted note.
+func isEncrypted(filename string) bool {
+	return strings.HasSuffix(filename, encryptedExt) && isNoteExt(baseExt(filename))
+}
+
+// readNote reads filename, relative to the todo directory, decrypting it
+// if it's encrypted.
+func (m *model) readNote(filename string) ([]byte, error) {
+	return readNoteFile(m.todoDir, m.cipher, filename)
+}
+
+// currentFileName returns the name of the note open in the editor,
+// relative to the todo directory.
+func (m model) currentFileName() string {
+	if m.encrypted {
+		return m.currentFile + m.currentExt + encryptedExt
+	}
+	return m.currentFile + m.currentExt
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffContext is how many unchanged lines are shown around each change.
+const diffContext = 3
+
+// diffMaxCells caps the table the LCS is worked out in. Past it, the
+// changed middle of the note is shown as removed and re-added.
+const diffMaxCells = 4_000_000
+
+var (
+	diffAddStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+// diffLine is a line of a diff; op is '+' for added, '-' for removed and
+// ' ' for unchanged.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the line diff turning a into b. The lines both start
+// and end with are set aside first, so the LCS only covers what changed.
+func diffLines(a, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out []diffLine
+	for _, line := range a[:prefix] {
+		out = append(out, diffLine{' ', line})
+	}
+	out = append(out, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, diffLine{' ', line})
+	}
+	return out
+}
+
+// diffMiddle diffs a and b by their longest common subsequence.
+func diffMiddle(a, b []string) []diffLine {
+	var out []diffLine
+	if (len(a)+1)*(len(b)+1) > diffMaxCells {
+		for _, line := range a {
+			out = append(out, diffLine{'-', line})
+		}
+		for _, line := range b {
+			out = append(out, diffLine{'+', line})
+		}
+		return out
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, diffLine{'-', a[i]})
+			i++
+		default:
+			out = append(out, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return out
+}
+
+// renderDiff colors a diff, leaving out unchanged lines further than
+// diffContext from a change, and counts the lines added and removed.
+func renderDiff(diff []diffLine) (view string, added, removed int) {
+	near := make([]bool, len(diff))
+	for i, line := range diff {
+		if line.op == ' ' {
+			continue
+		}
+		for k := max(i-diffContext, 0); k <= min(i+diffContext, len(diff)-1); k++ {
+			near[k] = true
+		}
+	}
+
+	var lines []string
+	skipped := false
+	for i, line := range diff {
+		if !near[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			lines = append(lines, lineNumberStyle.Render("⋯"))
+			skipped = false
+		}
+		switch line.op {
+		case '+':
+			added++
+			lines = append(lines, diffAddStyle.Render("+ "+line.text))
+		case '-':
+			removed++
+			lines = append(lines, diffDeleteStyle.Render("- "+line.text))
+		default:
+			lines = append(lines, "  "+line.text)
+		}
+	}
+	if skipped && len(lines) > 0 {
+		lines = append(lines, lineNumberStyle.Render("⋯"))
+	}
+	return strings.Join(lines, "\n"), added, removed
+}
+
+// openDiff shows what the buffer changes compared with the saved note.
+// A note that hasn't been saved yet is all additions.
+func (m *model) openDiff() {
+	var saved []string
+	content, err := m.readNote(m.currentFileName())
+	switch {
+	case err == nil:
+		saved = strings.Split(normalizeNewlines(string(content)), "\n")
+	case !errors.Is(err, fs.ErrNotExist):
+		m.editorStatus = "Can't read the saved note: " + err.Error()
+		return
+	}
+
+	view, added, removed := renderDiff(diffLines(saved, strings.Split(m.editor.Value(), "\n")))
+	if added == 0 && removed == 0 {
+		m.editorStatus = "No changes since the last save"
+		return
+	}
+	m.diffSummary = fmt.Sprintf("%s %s", diffAddStyle.Render(fmt.Sprintf("+%d", added)), diffDeleteStyle.Render(fmt.Sprintf("-%d", removed)))
+	m.diffViewport = viewport.New(0, 0)
+	m.diffViewport.SetContent(view)
+	m.sizeDiff()
+	m.editor.Blur()
+	m.state = diffView
+}
+
+// sizeDiff fits the diff viewport under its header and above its help.
+func (m *model) sizeDiff() {
+	h, v := docStyle.GetFrameSize()
+	titleHeight := lipgloss.Height(appTitleStyle.Render("Todo App"))
+	m.diffViewport.Width = m.width - h
+	m.diffViewport.Height = max(m.height-v-titleHeight-5, 1)
+}
+
+// closeDiff goes back to editing.
+func (m *model) closeDiff() tea.Cmd {
+	m.state = editorView
+	m.editor.Focus()
+	return tea.Batch(textarea.Blink, m.startAutosave())
+}
+
+// diffPageView renders the diff with its header and help.
+func (m model) diffPageView() string {
+	appTitle := appTitleStyle.Render("Todo App")
+	header := fmt.Sprintf("\n  Changes to %s since it was saved  %s\n\n", m.currentFileName(), m.diffSummary)
+	help := helpStyle.Render("(↑/↓ to scroll, esc to go back to editing)")
+	return docStyle.Render(appTitle + header + m.diffViewport.View() + "\n\n" + help)
+}
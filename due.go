@@ -0,0 +1,78 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// dueLinePattern matches a "due: <date>" line in the body of a note.
+var dueLinePattern = regexp.MustCompile(`(?mi)^\s*due:\s*(\S.*?)\s*$`)
+
+var overdueColor = lipgloss.Color("#FF5F87")
+
+// parseDue returns the due date of a note, from its frontmatter or else
+// the first "due:" line. Missing or unparseable dates yield zero.
+func parseDue(content string) time.Time {
+	lines, body, _ := splitFrontmatter(content)
+	if v, ok := frontmatterField(lines, "due"); ok {
+		if due, ok := parseMetaTime(v); ok {
+			return due
+		}
+	}
+	if match := dueLinePattern.FindStringSubmatch(body); match != nil {
+		if due, ok := parseMetaTime(match[1]); ok {
+			return due
+		}
+	}
+	return time.Time{}
+}
+
+// dueBefore orders due dates soonest first, with undated notes last.
+func dueBefore(a, b time.Time) bool {
+	if a.IsZero() || b.IsZero() {
+		return !a.IsZero() && b.IsZero()
+	}
+	return a.Before(b)
+}
+
+// isOverdue reports whether due has passed. Dates are due by the end of
+// the day, so a note due today isn't overdue yet.
+func isOverdue(due, now time.Time) bool {
+	if due.IsZero() {
+		return false
+	}
+	y, mo, d := now.Date()
+	return due.Before(time.Date(y, mo, d, 0, 0, 0, 0, now.Location()))
+}
+
+// todoDelegate renders todo items, coloring labelled ones and
+// highlighting overdue ones. numbered prefixes each with its position,
+// for jumping to by number.
+type todoDelegate struct {
+	list.DefaultDelegate
+	numbered bool
+}
+
+func newTodoDelegate() todoDelegate {
+	return todoDelegate{DefaultDelegate: list.NewDefaultDelegate()}
+}
+
+func (d todoDelegate) Render(w io.Writer, m list.Model, index int, it list.Item) {
+	todo, ok := it.(todoItem)
+	if ok && todo.label != "" {
+		color := labelColors[todo.label]
+		d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(color)
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(color).BorderForeground(color)
+	} else if ok && isOverdue(todo.due, time.Now()) {
+		d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(overdueColor)
+		d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(overdueColor).BorderForeground(overdueColor)
+	}
+	if item, ok := it.(list.DefaultItem); ok && d.numbered {
+		it = numberedItem{item, index + 1}
+	}
+	d.DefaultDelegate.Render(w, m, index, it)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// defaultEditedWindow is how recently a note must have changed to be
+// badged in the todo list, unless $GOTODO_EDITED_WINDOW says otherwise.
+const defaultEditedWindow = 24 * time.Hour
+
+var editedBadgeStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("0")).
+	Background(lipgloss.Color("114")).
+	Padding(0, 1)
+
+// editedWindowFromEnv returns the window set by $GOTODO_EDITED_WINDOW, a
+// duration like "6h". Zero turns the badge off.
+func editedWindowFromEnv() time.Duration {
+	window, err := time.ParseDuration(os.Getenv("GOTODO_EDITED_WINDOW"))
+	if err != nil || window < 0 {
+		return defaultEditedWindow
+	}
+	return window
+}
+
+// recentlyEdited reports whether modified falls within window of now.
+func recentlyEdited(modified, now time.Time, window time.Duration) bool {
+	return window > 0 && !modified.IsZero() && now.Sub(modified) < window
+}
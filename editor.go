@@ -0,0 +1,366 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type textCounts struct {
+	words, chars, lines int
+	done, tasks         int
+}
+
+func countText(s string) textCounts {
+	done, tasks := countCheckboxes(s)
+	return textCounts{
+		words: len(strings.Fields(s)),
+		chars: utf8.RuneCountInString(s),
+		lines: strings.Count(s, "\n") + 1,
+		done:  done,
+		tasks: tasks,
+	}
+}
+
+func (c textCounts) String() string {
+	return fmt.Sprintf("%d words · %d chars · %d lines", c.words, c.chars, c.lines)
+}
+
+// taskProgressWidth is the width of the checklist progress bar.
+const taskProgressWidth = 20
+
+// newTaskProgress returns the bar showing how much of a checklist is done.
+func newTaskProgress() progress.Model {
+	return progress.New(
+		progress.WithDefaultGradient(),
+		progress.WithWidth(taskProgressWidth),
+		progress.WithoutPercentage(),
+	)
+}
+
+// taskProgressView renders the checklist progress for the editor footer,
+// or nothing when the note has no checkboxes.
+func (m model) taskProgressView() string {
+	if m.counts.tasks == 0 {
+		return ""
+	}
+	percent := float64(m.counts.done) / float64(m.counts.tasks)
+	return m.taskProgress.ViewAs(percent) + helpStyle.UnsetMarginTop().Render(fmt.Sprintf(" %d/%d done", m.counts.done, m.counts.tasks))
+}
+
+// openAtEndFromEnv reports whether $GOTODO_OPEN_AT_END asks for notes to
+// open with the cursor at the end, ready to append to.
+func openAtEndFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("GOTODO_OPEN_AT_END"))
+	return enabled
+}
+
+// dateFormatsFromEnv returns the Go time layouts the editor inserts for
+// the date and the date and time, from $GOTODO_DATE_FORMAT and
+// $GOTODO_DATETIME_FORMAT.
+func dateFormatsFromEnv() (date, dateTime string) {
+	date, dateTime = "2006-01-02", "2006-01-02 15:04"
+	if format := os.Getenv("GOTODO_DATE_FORMAT"); format != "" {
+		date = format
+	}
+	if format := os.Getenv("GOTODO_DATETIME_FORMAT"); format != "" {
+		dateTime = format
+	}
+	return date, dateTime
+}
+
+// editorNoWrapSlack is how many columns the textarea is made wider than
+// the longest line when lines aren't wrapped, so typing doesn't have to
+// resize it on every key.
+const editorNoWrapSlack = 256
+
+// editorGutter is the width the line numbers take, if they're shown. The
+// textarea pads them to the digits of MaxHeight, which also caps how many
+// lines the buffer holds, with a space either side.
+func (m model) editorGutter() int {
+	if !m.editor.ShowLineNumbers {
+		return 0
+	}
+	return len(strconv.Itoa(m.editor.MaxHeight)) + 2
+}
+
+// longestLine returns the width of the widest line in s.
+func longestLine(s string) int {
+	widest := 0
+	for _, line := range strings.Split(s, "\n") {
+		widest = max(widest, ansi.StringWidth(line))
+	}
+	return widest
+}
+
+// fitNoWrapWidth widens the unwrapped textarea when a line has grown too
+// long for it. The textarea always soft-wraps at its width, so it's kept
+// wider than every line and editorView crops it to the screen.
+func (m *model) fitNoWrapWidth() {
+	if !m.editorNoWrap {
+		return
+	}
+	widest := longestLine(m.editor.Value())
+	if widest < m.editor.Width() {
+		return
+	}
+	m.editor.SetWidth(widest + editorNoWrapSlack + m.editorGutter() + m.editor.FocusedStyle.Base.GetHorizontalFrameSize())
+}
+
+// sizeEditor fits the editor to its pane, or widens it past the screen
+// when lines aren't wrapped.
+func (m *model) sizeEditor() {
+	_, v := docStyle.GetFrameSize()
+	if m.zen {
+		m.editor.SetHeight(m.height - 2*zenMarginY - 2)
+	} else {
+		// Leave room for the title, header and help text
+		titleHeight := lipgloss.Height(appTitleStyle.Render("Todo App"))
+		m.editor.SetHeight(m.height - v - 6 - titleHeight)
+	}
+
+	if m.editorNoWrap {
+		m.editor.MaxWidth = 0
+		m.editor.SetWidth(m.editorPaneWidth())
+		m.fitNoWrapWidth()
+		return
+	}
+	m.editor.SetWidth(m.editorPaneWidth())
+}
+
+// editorView renders the editor. Without wrapping, the oversized textarea
+// is cropped to the screen, scrolled sideways to keep the cursor in view.
+func (m model) editorView() string {
+	if m.readOnly {
+		m.editor.Blur()
+	}
+	view := m.editor.View()
+	if !m.editorNoWrap {
+		return view
+	}
+
+	border := m.editor.BlurredStyle.Base
+	if m.editor.Focused() {
+		border = m.editor.FocusedStyle.Base
+	}
+	gutter := m.editorGutter()
+	textWidth := max(m.editorPaneWidth()-border.GetHorizontalFrameSize()-gutter, 1)
+	xOffset := max(m.editor.LineInfo().CharOffset-textWidth+1, 0)
+
+	// Drop the textarea's own border and draw it again around the crop
+	lines := strings.Split(view, "\n")
+	lines = lines[border.GetBorderTopSize() : len(lines)-border.GetBorderBottomSize()]
+	for i, line := range lines {
+		inner := ansi.Cut(line, border.GetBorderLeftSize(), ansi.StringWidth(line)-border.GetBorderRightSize())
+		lines[i] = ansi.Cut(inner, 0, gutter) +
+			ansi.Cut(inner, gutter+xOffset, gutter+xOffset+textWidth)
+	}
+	return border.Render(strings.Join(lines, "\n"))
+}
+
+// normalizeNewlines turns Windows line endings into the plain newlines
+// the editor works with; the textarea would read each \r\n as two.
+func normalizeNewlines(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
+
+// loadEditor replaces the editor content with text freshly read from (or
+// about to be written to) disk, so the buffer starts out clean. The cursor
+// starts at the top, or at the end when openAtEnd is set.
+func (m *model) loadEditor(content string) {
+	content = normalizeNewlines(content)
+	m.editor.SetValue(content)
+	if m.openAtEnd {
+		m.setEditorCursor(m.editor.LineCount()-1, len(content))
+	} else {
+		// Pick up where the note was left, as far as it still goes
+		pos := m.cursors[m.currentFileName()]
+		m.setEditorCursor(pos.Row, pos.Col)
+	}
+	m.dirty = false
+	m.readOnly = false
+	m.counts = countText(content)
+	m.meta = parseMeta(content)
+	m.diskModTime = m.noteModTime()
+	m.resetUndo()
+}
+
+// cursorPos is where the cursor was left in a note, kept across
+// sessions.
+type cursorPos struct {
+	Row int `json:"row"`
+	Col int `json:"col"`
+}
+
+// rememberCursor records the cursor position in the note being edited,
+// for loadEditor to restore next time it's opened.
+func (m *model) rememberCursor() {
+	if m.cursors == nil {
+		m.cursors = make(map[string]cursorPos)
+	}
+	row, col := m.editorCursor()
+	m.cursors[m.currentFileName()] = cursorPos{Row: row, Col: col}
+}
+
+// existingCursors returns the cursor positions of notes that are still
+// in the todo directory.
+func (m model) existingCursors() map[string]cursorPos {
+	cursors := make(map[string]cursorPos)
+	for filename, pos := range m.cursors {
+		if _, err := os.Stat(filepath.Join(m.todoDir, filename)); err == nil {
+			cursors[filename] = pos
+		}
+	}
+	return cursors
+}
+
+// editorCursor returns the editor cursor as a row and column into the
+// buffer, independent of soft wrapping.
+func (m *model) editorCursor() (row, col int) {
+	li := m.editor.LineInfo()
+	return m.editor.Line(), li.StartColumn + li.ColumnOffset
+}
+
+// setEditorCursor moves the editor cursor to row and col, clamping both
+// to the buffer.
+func (m *model) setEditorCursor(row, col int) {
+	row = min(max(row, 0), m.editor.LineCount()-1)
+	for m.editor.Line() > row {
+		m.editor.CursorUp()
+	}
+	for m.editor.Line() < row {
+		m.editor.CursorDown()
+	}
+	m.editor.SetCursor(col)
+}
+
+// setEditorValue replaces the editor content as a single undoable edit,
+// keeps the cursor where it was and marks the buffer dirty.
+func (m *model) setEditorValue(value string) {
+	row, col := m.editorCursor()
+	m.pushUndo(m.snapshot(m.editor.Value()))
+	m.lastEditAt = time.Time{}
+	m.editor.SetValue(value)
+	m.setEditorCursor(row, col)
+	m.dirty = true
+	m.counts = countText(value)
+	m.autosaved = false
+}
+
+// insertText types s at the cursor as a single undoable edit.
+func (m *model) insertText(s string) {
+	m.pushUndo(m.snapshot(m.editor.Value()))
+	m.lastEditAt = time.Time{}
+	m.editor.InsertString(s)
+	m.dirty = true
+	m.autosaved = false
+	m.editorStatus = ""
+	m.counts = countText(m.editor.Value())
+}
+
+// toggleCurrentCheckbox flips the markdown checkbox on the cursor line.
+func (m *model) toggleCurrentCheckbox() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row := m.editor.Line()
+	if row >= len(lines) {
+		return
+	}
+
+	toggled, ok := toggleCheckbox(lines[row])
+	if !ok {
+		return
+	}
+	lines[row] = toggled
+	m.setEditorValue(strings.Join(lines, "\n"))
+}
+
+// duplicateLine copies the cursor line below itself, moving the cursor
+// onto the copy.
+func (m *model) duplicateLine() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row, col := m.editorCursor()
+	if row >= len(lines) {
+		return
+	}
+	lines = append(lines[:row+1], append([]string{lines[row]}, lines[row+1:]...)...)
+	m.setEditorValue(strings.Join(lines, "\n"))
+	m.setEditorCursor(row+1, col)
+}
+
+// moveLine swaps the cursor line with the one above it, or below it when
+// step is 1, taking the cursor along.
+func (m *model) moveLine(step int) {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row, col := m.editorCursor()
+	target := row + step
+	if row >= len(lines) || target < 0 || target >= len(lines) {
+		return
+	}
+	lines[row], lines[target] = lines[target], lines[row]
+	m.setEditorValue(strings.Join(lines, "\n"))
+	m.setEditorCursor(target, col)
+}
+
+// jumpToOpenTask moves the cursor to the text of the next unchecked box
+// after the cursor line, or the previous one when step is -1, wrapping
+// around the ends of the buffer.
+func (m *model) jumpToOpenTask(step int) {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row := m.editor.Line()
+	for i := 1; i <= len(lines); i++ {
+		r := ((row+i*step)%len(lines) + len(lines)) % len(lines)
+		match := checkboxPattern.FindStringSubmatch(lines[r])
+		if match == nil || match[2] != " " {
+			continue
+		}
+		text := len([]rune(match[1])) + 2
+		if strings.HasPrefix(match[3], "] ") {
+			text++
+		}
+		m.setEditorCursor(r, text)
+		if (step > 0) != (r > row) {
+			m.editorStatus = "Wrapped around"
+		}
+		return
+	}
+	m.editorStatus = "No open tasks"
+}
+
+type externalEditorFinishedMsg struct {
+	err error
+}
+
+// openExternalEditor saves the buffer and suspends the program to edit
+// the file in $EDITOR. The file is reloaded once externalEditorFinishedMsg
+// arrives.
+func (m *model) openExternalEditor() tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		m.editorStatus = "$EDITOR is not set"
+		return nil
+	}
+	if m.encrypted {
+		m.editorStatus = "Encrypted notes can't be opened in $EDITOR"
+		return nil
+	}
+	if m.lastErr = m.saveFile(); m.lastErr != nil {
+		return nil
+	}
+
+	// $EDITOR may carry arguments, e.g. "code --wait"
+	args := strings.Fields(editor)
+	c := exec.Command(args[0], append(args[1:], m.currentFilePath())...)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return externalEditorFinishedMsg{err: err}
+	})
+}
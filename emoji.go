@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// shortcodes are the :name: tokens the editor turns into emoji.
+var shortcodes = map[string]string{
+	"+1":               "👍",
+	"-1":               "👎",
+	"bug":              "🐛",
+	"calendar":         "📅",
+	"check":            "✔️",
+	"clock":            "🕒",
+	"construction":     "🚧",
+	"eyes":             "👀",
+	"fire":             "🔥",
+	"heart":            "❤️",
+	"hourglass":        "⌛",
+	"idea":             "💡",
+	"memo":             "📝",
+	"pin":              "📌",
+	"question":         "❓",
+	"rocket":           "🚀",
+	"smile":            "😄",
+	"sparkles":         "✨",
+	"star":             "⭐",
+	"tada":             "🎉",
+	"thinking":         "🤔",
+	"warning":          "⚠️",
+	"white_check_mark": "✅",
+	"x":                "❌",
+	"zap":              "⚡",
+}
+
+// shortcodePattern matches a :name: token at the end of the text before
+// the cursor.
+var shortcodePattern = regexp.MustCompile(`(?:^|\s):([a-z0-9_+-]+):$`)
+
+// emojiFromEnv reports whether shortcodes are expanded, which they are
+// unless $GOTODO_EMOJI turns them off.
+func emojiFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("GOTODO_EMOJI"))
+	return err != nil || enabled
+}
+
+// emojiConfigPath returns where extra shortcodes are read from, normally
+// ~/.config/gotodo/emoji.json, a map like {"coffee": "☕"}.
+func emojiConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotodo", "emoji.json"), nil
+}
+
+// loadEmojiConfig adds the shortcodes in path to the built-in ones,
+// replacing any of the same name. A missing file is fine.
+func loadEmojiConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var extra map[string]string
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for name, emoji := range extra {
+		shortcodes[strings.Trim(name, ":")] = emoji
+	}
+	return nil
+}
+
+// expandShortcode replaces a known :name: token just before the cursor
+// with its emoji.
+func (m *model) expandShortcode() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row, col := m.editorCursor()
+	if row >= len(lines) {
+		return
+	}
+	line := []rune(lines[row])
+	col = min(col, len(line))
+	match := shortcodePattern.FindStringSubmatch(string(line[:col]))
+	if match == nil {
+		return
+	}
+	emoji, ok := shortcodes[match[1]]
+	if !ok {
+		return
+	}
+	start := col - len([]rune(match[1])) - 2
+	lines[row] = string(line[:start]) + emoji + string(line[col:])
+	m.setEditorValue(strings.Join(lines, "\n"))
+	m.setEditorCursor(row, start+len([]rune(emoji)))
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var htmlExportTemplate = template.Must(template.New("export").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { max-width: 46rem; margin: 2rem auto; padding: 0 1rem; font: 16px/1.6 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292f; }
+h1, h2, h3 { line-height: 1.25; }
+code, pre { font-family: ui-monospace, Menlo, Consolas, monospace; background: #f6f8fa; border-radius: 4px; }
+code { padding: .1em .3em; }
+pre { padding: 1em; overflow-x: auto; }
+pre code { padding: 0; }
+blockquote { margin: 0; padding-left: 1em; border-left: 4px solid #d0d7de; color: #57606a; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: .3em .8em; }
+ul.contains-task-list, li:has(> input[type=checkbox]) { list-style: none; }
+</style>
+</head>
+<body>
+{{.Body}}
+</body>
+</html>
+`))
+
+// markdownToHTML renders a markdown note as a standalone HTML page.
+func markdownToHTML(title, markdown string) ([]byte, error) {
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	var body bytes.Buffer
+	if err := md.Convert([]byte(markdown), &body); err != nil {
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	err := htmlExportTemplate.Execute(&page, struct {
+		Title string
+		Body  template.HTML
+	}{title, template.HTML(body.String())})
+	if err != nil {
+		return nil, err
+	}
+	return page.Bytes(), nil
+}
+
+// exportHTML writes the markdown for filename, relative to the todo
+// directory, to an .html file alongside it and returns the new name.
+func (m *model) exportHTML(filename, markdown string) (string, error) {
+	htmlName := noteName(filename) + ".html"
+	page, err := markdownToHTML(filepath.Base(filename), stripFrontmatter(markdown))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(m.todoDir, htmlName), page, 0644); err != nil {
+		return "", err
+	}
+	return htmlName, nil
+}
+
+// exportAllPattern matches the files Export All writes by default, so
+// one review's export isn't folded into the next.
+var exportAllPattern = regexp.MustCompile(`^export-\d{4}-\d{2}-\d{2}\.md$`)
+
+// exportAllName is the default name for an export made at now.
+func exportAllName(now time.Time) string {
+	return "export-" + now.Format("2006-01-02") + ".md"
+}
+
+// exportAll concatenates every note in dir, in name order and each under
+// a "# name" heading, into out. out is relative to dir unless it's
+// absolute. Encrypted notes are left out rather than written in the
+// clear; skipped says how many were. Notes are written out one at a time
+// to a temporary file, calling progress after each, which replaces out
+// only once they're all in. Cancelling ctx stops the export and leaves
+// out as it was.
+func exportAll(ctx context.Context, dir, out string, subfolders bool, progress func(done, total int)) (path string, count, skipped int, err error) {
+	path = out
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	var names []string
+	err = filepath.WalkDir(dir, func(p string, file fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if file.IsDir() {
+			if p != dir && (!subfolders || strings.HasPrefix(file.Name(), ".") || p == filepath.Join(dir, archiveDirName)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p == path || !isNoteFile(file.Name()) {
+			return nil
+		}
+		if filepath.Dir(p) == dir && exportAllPattern.MatchString(file.Name()) {
+			return nil
+		}
+		if isEncrypted(file.Name()) {
+			skipped++
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", 0, 0, err
+	}
+	sort.Strings(names)
+	progress(0, len(names))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, 0, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	for i, name := range names {
+		if err := ctx.Err(); err != nil {
+			return "", 0, 0, err
+		}
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", 0, 0, err
+		}
+		if i > 0 {
+			w.WriteString("\n\n")
+		}
+		fmt.Fprintf(w, "# %s\n\n%s", name, strings.TrimSpace(stripFrontmatter(string(content))))
+		progress(i+1, len(names))
+	}
+	w.WriteString("\n")
+
+	if err := w.Flush(); err != nil {
+		return "", 0, 0, err
+	}
+	if err := f.Chmod(0644); err != nil {
+		return "", 0, 0, err
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, 0, err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", 0, 0, err
+	}
+	return path, len(names), skipped, nil
+}
+
+// exportAllStatus describes a finished export for the main menu.
+func exportAllStatus(path string, count, skipped int) string {
+	status := fmt.Sprintf("Exported %d note(s) to %s", count, path)
+	if skipped > 0 {
+		status += fmt.Sprintf(" (%d encrypted skipped)", skipped)
+	}
+	return status
+}
+
+// exportProgressWidth is how wide the Export All progress bar is.
+const exportProgressWidth = 40
+
+// exportAllProgressMsg reports how far a running Export All has got.
+type exportAllProgressMsg struct {
+	done, total int
+}
+
+// exportAllDoneMsg reports how an Export All run in the background went.
+type exportAllDoneMsg struct {
+	path           string
+	count, skipped int
+	err            error
+}
+
+// startExportAll runs Export All off the update loop, since it reads
+// every note, and shows its progress until it's done or cancelled.
+func (m *model) startExportAll(out string, subfolders bool) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tea.Msg)
+	dir := m.todoDir
+	go func() {
+		defer close(updates)
+		path, count, skipped, err := exportAll(ctx, dir, out, subfolders, func(done, total int) {
+			updates <- exportAllProgressMsg{done: done, total: total}
+		})
+		updates <- exportAllDoneMsg{path: path, count: count, skipped: skipped, err: err}
+	}()
+
+	m.exportCancel = cancel
+	m.exportUpdates = updates
+	m.exportDone, m.exportTotal = 0, 0
+	m.exportProgress = progress.New(progress.WithDefaultGradient(), progress.WithWidth(exportProgressWidth))
+	m.state = exportingView
+	return m.waitExportAll()
+}
+
+// waitExportAll delivers the next update from the running Export All.
+func (m *model) waitExportAll() tea.Cmd {
+	updates := m.exportUpdates
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// cancelExportAll asks the running Export All to stop. It winds down
+// with an exportAllDoneMsg like any other run.
+func (m *model) cancelExportAll() {
+	if m.exportCancel != nil {
+		m.exportCancel()
+		m.exportCancel = nil
+	}
+}
+
+// exportingView shows how far Export All has got.
+func (m model) exportingView() string {
+	content := "Exporting..."
+	if m.exportTotal > 0 {
+		percent := float64(m.exportDone) / float64(m.exportTotal)
+		content = fmt.Sprintf("Exporting all todos: %d/%d exported\n\n%s", m.exportDone, m.exportTotal, m.exportProgress.ViewAs(percent))
+	}
+	help := helpStyle.Render("(esc to cancel)")
+	if m.exportCancel == nil {
+		help = helpStyle.Render("(cancelling...)")
+	}
+	return docStyle.Render(content + "\n\n" + help)
+}
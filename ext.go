@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// noteExtensions are the file extensions listed as notes, configured with
+// $GOTODO_EXTENSIONS. The first is given to new notes named without one.
+var noteExtensions = []string{".md"}
+
+// markdownExtensions are the note extensions previewed as markdown;
+// anything else is shown as plain text.
+var markdownExtensions = []string{".md", ".markdown"}
+
+// noteExtensionsFromEnv parses $GOTODO_EXTENSIONS, a comma separated list
+// like "md,txt", falling back to just markdown when it's unset or empty.
+func noteExtensionsFromEnv() []string {
+	var exts []string
+	for _, ext := range strings.Split(os.Getenv("GOTODO_EXTENSIONS"), ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if ext != encryptedExt && !slices.Contains(exts, ext) {
+			exts = append(exts, ext)
+		}
+	}
+	if len(exts) == 0 {
+		return []string{".md"}
+	}
+	return exts
+}
+
+// baseExt returns the extension of filename, ignoring the one added when
+// it's encrypted.
+func baseExt(filename string) string {
+	return filepath.Ext(strings.TrimSuffix(filename, encryptedExt))
+}
+
+// isNoteExt reports whether ext is one of the configured note extensions.
+func isNoteExt(ext string) bool {
+	return slices.Contains(noteExtensions, strings.ToLower(ext))
+}
+
+// isNoteFile reports whether filename is a note, plain or encrypted.
+func isNoteFile(filename string) bool {
+	return isNoteExt(baseExt(filename))
+}
+
+// isMarkdown reports whether filename should be rendered as markdown.
+func isMarkdown(filename string) bool {
+	return slices.Contains(markdownExtensions, strings.ToLower(baseExt(filename)))
+}
+
+// noteExt returns the extension of the note filename, including the
+// encrypted suffix if it has one.
+func noteExt(filename string) string {
+	if isEncrypted(filename) {
+		return baseExt(filename) + encryptedExt
+	}
+	return filepath.Ext(filename)
+}
+
+// noteName returns filename without its note extension.
+func noteName(filename string) string {
+	return strings.TrimSuffix(filename, noteExt(filename))
+}
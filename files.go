@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reservedNameChars can't be used in note names. Some filesystems refuse
+// them, and a backslash would be a folder separator on Windows only.
+const reservedNameChars = `\:*?"<>|`
+
+// todoFileName turns a name typed by the user into a note path relative
+// to the todo directory, rejecting names that would land outside it or
+// that have no base name (like "work/"). A note extension typed with the
+// name is kept; otherwise any extension is replaced with defaultExt.
+func todoFileName(input, defaultExt string) (string, error) {
+	// Spaces around folder names are dropped, spaces inside them kept
+	parts := strings.Split(strings.TrimSpace(input), "/")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	name := strings.Join(parts, "/")
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+	if !isNoteExt(ext) {
+		ext = defaultExt
+	}
+	if name == "" || strings.HasSuffix(name, "/") || filepath.Base(name) == "." {
+		return "", errors.New("name is empty")
+	}
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%s is outside the todo directory", strings.TrimSpace(input))
+	}
+	if err := checkNoteName(name + ext); err != nil {
+		return "", err
+	}
+	return name + ext, nil
+}
+
+// checkNoteName rejects note paths with characters or folder names that
+// would make a file that can't be saved or wouldn't show in the list.
+func checkNoteName(filename string) error {
+	if i := strings.IndexAny(filename, reservedNameChars); i >= 0 {
+		return fmt.Errorf("names can't contain %q", filename[i])
+	}
+	for _, r := range filename {
+		if r < ' ' || r == 0x7f {
+			return errors.New("names can't contain control characters")
+		}
+	}
+	for _, part := range strings.Split(filename, "/") {
+		switch {
+		case part == "":
+			return errors.New("folder names can't be empty")
+		case strings.HasPrefix(part, "."):
+			return fmt.Errorf("%s is hidden, names can't start with a dot", part)
+		}
+	}
+	return nil
+}
+
+// existingNote returns the note already saved under filename, plain or
+// encrypted, if there is one.
+func (m *model) existingNote(filename string) (string, bool) {
+	for _, name := range []string{filename, filename + encryptedExt} {
+		if _, err := os.Stat(filepath.Join(m.todoDir, name)); err == nil {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// duplicateTodo copies filename, relative to the todo directory, to a
+// free "<name>-copy" sibling and returns the new relative name.
+func (m *model) duplicateTodo(filename string) (newName string, err error) {
+	defer func() { logOp("duplicate", filename, err) }()
+	content, err := os.ReadFile(filepath.Join(m.todoDir, filename))
+	if err != nil {
+		return "", err
+	}
+
+	// The copy keeps the original's bytes, so an encrypted note stays
+	// encrypted
+	ext := noteExt(filename)
+	base := noteName(filename) + "-copy"
+	newName = base + ext
+	for n := 2; ; n++ {
+		// O_EXCL claims the name atomically; any error other than the
+		// name being taken (e.g. it's too long) would repeat forever.
+		f, err := os.OpenFile(filepath.Join(m.todoDir, newName), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			newName = fmt.Sprintf("%s-%d%s", base, n, ext)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(content); err != nil {
+			f.Close()
+			return "", err
+		}
+		return newName, f.Close()
+	}
+}
+
+// togglePin pins or unpins filename and reports which it did.
+func (m *model) togglePin(filename string) string {
+	if m.pinned[filename] {
+		delete(m.pinned, filename)
+		return "Unpinned"
+	}
+	m.pinned[filename] = true
+	return "Pinned"
+}
+
+// renamedNote returns the name oldName would have if renamed to input,
+// keeping its extension unless input has another and keeping it
+// encrypted if it is.
+func renamedNote(oldName, input string) (string, error) {
+	newName, err := todoFileName(input, baseExt(oldName))
+	if err != nil {
+		return "", err
+	}
+	if isEncrypted(oldName) {
+		newName += encryptedExt
+	}
+	return newName, nil
+}
+
+// renameNote moves oldName to newName within the todo directory, taking
+// its pin, mark and label along. A note already called newName is never
+// replaced; that fails with fs.ErrExist.
+func (m *model) renameNote(oldName, newName string) (err error) {
+	defer func() { logOp("rename", oldName+" to "+newName, err) }()
+	newPath := filepath.Join(m.todoDir, newName)
+	if _, err := os.Stat(newPath); err == nil {
+		return fs.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(m.todoDir, oldName), newPath); err != nil {
+		return err
+	}
+	m.index.forget(oldName)
+	m.index.refresh(newName)
+	m.saveIndex()
+	if m.pinned[oldName] {
+		delete(m.pinned, oldName)
+		m.pinned[newName] = true
+	}
+	if m.marked[oldName] {
+		delete(m.marked, oldName)
+		m.marked[newName] = true
+	}
+	if label, ok := m.labels[oldName]; ok {
+		delete(m.labels, oldName)
+		m.labels[newName] = label
+	}
+	return nil
+}
+
+// defaultNamePattern is the time layout new note names are pre-filled
+// from, unless $GOTODO_NAME_PATTERN says otherwise.
+const defaultNamePattern = "2006-01-02-note"
+
+// namePatternFromEnv returns $GOTODO_NAME_PATTERN, or the default when
+// it's unset. Setting it empty leaves the name blank.
+func namePatternFromEnv() string {
+	if pattern, ok := os.LookupEnv("GOTODO_NAME_PATTERN"); ok {
+		return strings.TrimSpace(pattern)
+	}
+	return defaultNamePattern
+}
+
+// openCreate asks for a new note's name, pre-filled from the name
+// pattern in folder, going back to returnTo if it's cancelled.
+func (m *model) openCreate(returnTo viewState, folder string) tea.Cmd {
+	name := time.Now().Format(m.namePattern)
+	if folder != "" {
+		name = folder + "/" + name
+	}
+	m.returnTo = returnTo
+	m.state = createTodoView
+	m.textInput.SetValue(name)
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+	return textinput.Blink
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var breadcrumbStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("241")).
+	PaddingLeft(2)
+
+// folderItem is a subfolder of the one the todo list is showing. path is
+// relative to the todo directory, like a note's filename.
+type folderItem struct {
+	path  string
+	notes int
+}
+
+func (i folderItem) Title() string { return "📁 " + path.Base(i.path) + "/" }
+
+func (i folderItem) Description() string {
+	if i.notes == 1 {
+		return "1 note"
+	}
+	return fmt.Sprintf("%d notes", i.notes)
+}
+
+func (i folderItem) FilterValue() string { return path.Base(i.path) }
+
+// noteFolder returns the folder filename is in, relative to the todo
+// directory, or "" for the top level.
+func noteFolder(filename string) string {
+	if dir := path.Dir(filename); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// loadFolders lists every folder under dir that loadTodoFiles looks in,
+// relative to dir, so empty ones can still be browsed into.
+func loadFolders(dir string) []string {
+	archiveDir := filepath.Join(dir, archiveDirName)
+	var folders []string
+	filepath.WalkDir(dir, func(p string, file fs.DirEntry, err error) error {
+		if err != nil || !file.IsDir() || p == dir {
+			return nil
+		}
+		if strings.HasPrefix(file.Name(), ".") || p == archiveDir {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			folders = append(folders, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return folders
+}
+
+// folderItems returns the folders directly inside folder, each with the
+// number of notes anywhere beneath it.
+func folderItems(folders []string, notes []list.Item, folder string) []list.Item {
+	items := []list.Item{}
+	for _, f := range folders {
+		if noteFolder(f) != folder {
+			continue
+		}
+		item := folderItem{path: f}
+		for _, it := range notes {
+			if strings.HasPrefix(it.(todoItem).filename, f+"/") {
+				item.notes++
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// inFolder keeps the notes directly inside folder.
+func inFolder(items []list.Item, folder string) []list.Item {
+	kept := []list.Item{}
+	for _, it := range items {
+		if noteFolder(it.(todoItem).filename) == folder {
+			kept = append(kept, it)
+		}
+	}
+	return kept
+}
+
+// enterFolder shows the notes in folder, starting from the top.
+func (m *model) enterFolder(folder string) tea.Cmd {
+	m.todoFolder = folder
+	m.todoList.ResetFilter()
+	m.todoList.Select(0)
+	m.sizeTodoList()
+	cmd := m.reloadTodoList()
+	m.pendingSelect = ""
+	return cmd
+}
+
+// leaveFolder goes up to the folder holding the current one, selecting
+// the folder it came from.
+func (m *model) leaveFolder() tea.Cmd {
+	from := m.todoFolder
+	cmd := m.enterFolder(noteFolder(from))
+	m.pendingSelect = from
+	return cmd
+}
+
+// breadcrumbHeight is the number of rows breadcrumbView takes.
+func (m model) breadcrumbHeight() int {
+	if m.todoFolder == "" {
+		return 0
+	}
+	return 1
+}
+
+// breadcrumbView shows where in the todo directory the list is, like
+// "todo / work / sprint", once it's inside a folder.
+func (m model) breadcrumbView() string {
+	if m.todoFolder == "" {
+		return ""
+	}
+	parts := append([]string{filepath.Base(m.todoDir)}, strings.Split(m.todoFolder, "/")...)
+	return breadcrumbStyle.Render(strings.Join(parts, " / "))
+}
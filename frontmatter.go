@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// frontmatterDelim opens and closes a YAML frontmatter block.
+const frontmatterDelim = "---"
+
+// metaTimeLayout is the format of the created and updated timestamps.
+const metaTimeLayout = "2006-01-02 15:04"
+
+var noteMetaStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+var (
+	frontmatterBoxStyle = lipgloss.NewStyle().
+				Border(lipgloss.RoundedBorder()).
+				BorderForeground(lipgloss.Color("238")).
+				Padding(0, 1).
+				MarginLeft(2)
+	frontmatterKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// noteMeta is the metadata kept in a note's frontmatter.
+type noteMeta struct {
+	created, updated time.Time
+}
+
+// frontmatterKeyPattern matches the "key:" that starts a YAML field.
+var frontmatterKeyPattern = regexp.MustCompile(`^[A-Za-z_][\w-]*:(\s|$)`)
+
+// isFrontmatter reports whether lines read as YAML fields, so a note that
+// merely opens with a --- rule isn't mistaken for one with frontmatter.
+// Indented lines continue the field above them.
+func isFrontmatter(lines []string) bool {
+	fields := 0
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "", strings.HasPrefix(strings.TrimSpace(line), "#"):
+		case line[0] == ' ' || line[0] == '\t':
+			if fields == 0 {
+				return false
+			}
+		case frontmatterKeyPattern.MatchString(strings.TrimRight(line, "\r")):
+			fields++
+		default:
+			return false
+		}
+	}
+	return fields > 0
+}
+
+// lineEnding returns the line ending content uses, "\r\n" or "\n".
+func lineEnding(content string) string {
+	if strings.Contains(content, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// splitFrontmatter separates a leading frontmatter block from the rest of
+// content. lines holds the lines between the delimiters, without any
+// carriage returns; ok is false when content doesn't start with a
+// complete block of YAML fields.
+func splitFrontmatter(content string) (lines []string, body string, ok bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(content, frontmatterDelim+"\n"):
+		rest = content[len(frontmatterDelim)+1:]
+	case strings.HasPrefix(content, frontmatterDelim+"\r\n"):
+		rest = content[len(frontmatterDelim)+2:]
+	default:
+		return nil, content, false
+	}
+
+	for offset := 0; ; {
+		line, _, more := strings.Cut(rest[offset:], "\n")
+		if strings.TrimRight(line, " \r") == frontmatterDelim {
+			if !isFrontmatter(lines) {
+				return nil, content, false
+			}
+			if !more {
+				return lines, "", true
+			}
+			return lines, rest[offset+len(line)+1:], true
+		}
+		if !more {
+			return nil, content, false
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+		offset += len(line) + 1
+	}
+}
+
+// frontmatterField returns the value of key in the frontmatter lines.
+func frontmatterField(lines []string, key string) (string, bool) {
+	for _, line := range lines {
+		k, v, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(k) == key {
+			return strings.Trim(strings.TrimSpace(v), `"'`), true
+		}
+	}
+	return "", false
+}
+
+// frontmatterEntry is one top-level field of a frontmatter block.
+type frontmatterEntry struct {
+	key, value string
+}
+
+// frontmatterEntries reads the top-level fields of the frontmatter lines
+// in order. Indented list items are joined onto the field above them, as
+// are the items of an inline [a, b] list.
+func frontmatterEntries(lines []string) []frontmatterEntry {
+	var entries []frontmatterEntry
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+		case line[0] == ' ' || line[0] == '\t':
+			if len(entries) == 0 {
+				continue
+			}
+			item := strings.Trim(strings.TrimSpace(strings.TrimPrefix(trimmed, "-")), `"'`)
+			last := &entries[len(entries)-1]
+			if last.value != "" {
+				last.value += ", "
+			}
+			last.value += item
+		default:
+			k, v, _ := strings.Cut(line, ":")
+			v = strings.TrimSpace(v)
+			if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+				items := strings.Split(v[1:len(v)-1], ",")
+				for i, item := range items {
+					items[i] = strings.Trim(strings.TrimSpace(item), `"'`)
+				}
+				v = strings.Join(items, ", ")
+			}
+			entries = append(entries, frontmatterEntry{strings.TrimSpace(k), strings.Trim(v, `"'`)})
+		}
+	}
+	return entries
+}
+
+// frontmatterBox draws the frontmatter fields as a small table in a box
+// no wider than width, for the top of the preview. Fields without a value
+// are left out, and it's empty when none are left.
+func frontmatterBox(lines []string, width int) string {
+	var entries []frontmatterEntry
+	keyWidth := 0
+	for _, e := range frontmatterEntries(lines) {
+		if e.value == "" {
+			continue
+		}
+		entries = append(entries, e)
+		keyWidth = max(keyWidth, lipgloss.Width(e.key))
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+
+	// Leave room for the margin, border, padding and the gap after keys
+	valueWidth := max(width-frontmatterBoxStyle.GetHorizontalFrameSize()-keyWidth-2, 1)
+	rows := make([]string, len(entries))
+	for i, e := range entries {
+		key := frontmatterKeyStyle.Render(fmt.Sprintf("%-*s", keyWidth, e.key))
+		rows[i] = key + "  " + ansi.Truncate(e.value, valueWidth, "…")
+	}
+	return frontmatterBoxStyle.Render(strings.Join(rows, "\n"))
+}
+
+// parseMetaTime accepts the timestamp layouts people commonly write by
+// hand as well as our own.
+func parseMetaTime(s string) (time.Time, bool) {
+	for _, layout := range []string{metaTimeLayout, time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseMeta reads the metadata from content's frontmatter, leaving fields
+// that are missing or malformed zero.
+func parseMeta(content string) noteMeta {
+	var meta noteMeta
+
+	lines, _, ok := splitFrontmatter(content)
+	if !ok {
+		return meta
+	}
+	if v, ok := frontmatterField(lines, "created"); ok {
+		meta.created, _ = parseMetaTime(v)
+	}
+	if v, ok := frontmatterField(lines, "updated"); ok {
+		meta.updated, _ = parseMetaTime(v)
+	}
+	return meta
+}
+
+// stripFrontmatter returns content without its frontmatter block, for
+// rendering.
+func stripFrontmatter(content string) string {
+	_, body, _ := splitFrontmatter(content)
+	return body
+}
+
+// stampFrontmatter sets the updated timestamp in content's frontmatter.
+// New files get a block with both timestamps; existing files without one
+// are left alone rather than rewritten behind the user's back.
+func stampFrontmatter(content string, now time.Time, isNew bool) string {
+	stamp := now.Format(metaTimeLayout)
+	eol := lineEnding(content)
+
+	lines, body, ok := splitFrontmatter(content)
+	if !ok {
+		if !isNew {
+			return content
+		}
+		return frontmatterDelim + eol + "created: " + stamp + eol + "updated: " + stamp + eol + frontmatterDelim + eol + content
+	}
+
+	updated := false
+	for i, line := range lines {
+		if k, _, found := strings.Cut(line, ":"); found && strings.TrimSpace(k) == "updated" {
+			lines[i] = "updated: " + stamp
+			updated = true
+			break
+		}
+	}
+	if !updated {
+		lines = append(lines, "updated: "+stamp)
+	}
+
+	var b strings.Builder
+	b.WriteString(frontmatterDelim + eol)
+	for _, line := range lines {
+		b.WriteString(line + eol)
+	}
+	b.WriteString(frontmatterDelim + eol)
+	b.WriteString(body)
+	return b.String()
+}
+
+// String formats the metadata for the editor header.
+func (n noteMeta) String() string {
+	var parts []string
+	if !n.created.IsZero() {
+		parts = append(parts, "created "+n.created.Format(metaTimeLayout))
+	}
+	if !n.updated.IsZero() {
+		parts = append(parts, "updated "+n.updated.Format(metaTimeLayout))
+	}
+	return strings.Join(parts, " · ")
+}
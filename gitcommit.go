@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gitAutoCommitFromEnv reports whether $GOTODO_GIT_COMMIT opts in to
+// committing changes to the todo directory.
+func gitAutoCommitFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("GOTODO_GIT_COMMIT"))
+	return enabled
+}
+
+// gitMu runs automatic commits one at a time; two git processes at once
+// would trip over each other's index.lock.
+var gitMu sync.Mutex
+
+// gitCommitMsg reports the outcome of an automatic commit.
+type gitCommitMsg struct {
+	err error
+}
+
+// git runs a git command in dir, folding its output into the error.
+func git(dir string, args ...string) error {
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gitCommit returns a command that commits paths, relative to the todo
+// directory, in the background. It does nothing unless auto-commit is on
+// and the todo directory is inside a git repository.
+func (m *model) gitCommit(message string, paths ...string) tea.Cmd {
+	if !m.gitAutoCommit {
+		return nil
+	}
+
+	dir := m.todoDir
+	return func() tea.Msg {
+		gitMu.Lock()
+		defer gitMu.Unlock()
+
+		if exec.Command("git", "-C", dir, "rev-parse", "--is-inside-work-tree").Run() != nil {
+			return nil
+		}
+
+		pathspec := append([]string{"--"}, paths...)
+		if err := git(dir, append([]string{"add", "--all"}, pathspec...)...); err != nil {
+			return gitCommitMsg{err: err}
+		}
+		// Nothing staged means nothing changed, which isn't worth a commit
+		if exec.Command("git", append([]string{"-C", dir, "diff", "--cached", "--quiet"}, pathspec...)...).Run() == nil {
+			return nil
+		}
+		return gitCommitMsg{err: git(dir, append([]string{"commit", "--quiet", "-m", message}, pathspec...)...)}
+	}
+}
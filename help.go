@@ -0,0 +1,265 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	helpSectionStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("99")).
+				MarginTop(1)
+
+	helpKeyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("212")).
+			Width(16)
+
+	helpDescStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("250"))
+)
+
+type helpSection struct {
+	title    string
+	bindings []key.Binding
+}
+
+// takesTextInput reports whether the current view is collecting typed
+// text, in which case plain character shortcuts must not be intercepted.
+func (m model) takesTextInput() bool {
+	switch m.state {
+	case createTodoView, renameTodoView, renameNoteView, tagFilterView, exportAllView, renameTagView, editorView, replaceView, gotoLineView, paletteView:
+		return true
+	case searchView:
+		return m.searchInput.Focused()
+	case listView:
+		return m.mainList.FilterState() == list.Filtering
+	case todoListView:
+		return m.todoList.FilterState() == list.Filtering
+	case trashView:
+		return m.trashList.FilterState() == list.Filtering
+	case recentView:
+		return m.recentList.FilterState() == list.Filtering
+	case todayView:
+		return m.todayList.FilterState() == list.Filtering
+	case templateView:
+		return m.templateList.FilterState() == list.Filtering
+	case archiveView:
+		return m.archiveList.FilterState() == list.Filtering
+	case workspaceView:
+		return m.workspaceList.FilterState() == list.Filtering
+	}
+	return false
+}
+
+// bindingHelp formats bindings as "key: description" pairs for a footer,
+// leaving out disabled ones.
+func bindingHelp(bindings ...key.Binding) string {
+	var parts []string
+	for _, b := range bindings {
+		if b.Enabled() {
+			parts = append(parts, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// fitHelp drops the last hints from a footer built by bindingHelp until
+// it fits in width, marking that some were left out.
+func fitHelp(help string, width int) string {
+	parts := strings.Split(help, " | ")
+	for len(parts) > 0 && lipgloss.Width(help) > width {
+		parts = parts[:len(parts)-1]
+		help = strings.Join(append(parts, "…"), " | ")
+	}
+	if lipgloss.Width(help) > width {
+		return ""
+	}
+	return help
+}
+
+// previewHelpText is the preview footer, built the same way as the
+// editor's.
+func (m model) previewHelpText() string {
+	keys := m.previewKeys
+	theme := keys.theme
+	theme.SetHelp(theme.Help().Key, "theme ("+m.previewTheme+")")
+	bindings := []key.Binding{
+		keys.scroll, keys.topBottom, keys.halfPage, theme, keys.lineNums,
+		keys.raw, keys.wrap, keys.toc, keys.lint, keys.openLink,
+	}
+	if m.previewSideIndicator() != "" {
+		bindings = append(bindings[:1], append([]key.Binding{keys.side}, bindings[1:]...)...)
+	}
+	if m.tocVisible {
+		bindings = append(bindings, keys.tocPrev, keys.tocNext)
+	}
+	bindings = append(bindings, keys.export, keys.copy, keys.copyText, keys.back)
+	return bindingHelp(bindings...)
+}
+
+// editorHelpText is the editor footer, generated from its key map so it
+// always shows the keys actually bound. Saving and leaving come first, as
+// the footer is cut short on narrow screens.
+func (m model) editorHelpText() string {
+	keys := m.editorKeys
+	wrap := keys.wrap
+	if m.editorNoWrap {
+		wrap.SetHelp(wrap.Help().Key, "wrap (off)")
+	} else {
+		wrap.SetHelp(wrap.Help().Key, "wrap (on)")
+	}
+	if m.readOnly {
+		return bindingHelp(keys.cancel, keys.readOnly, keys.preview, keys.search, keys.gotoLine, wrap)
+	}
+	return bindingHelp(
+		keys.save, keys.saveExit, keys.cancel, keys.preview, keys.toggleTask, keys.replace, keys.search,
+		keys.gotoLine, keys.external, keys.undo, keys.redo, wrap, keys.split,
+	)
+}
+
+func (m model) helpSections() []helpSection {
+	return []helpSection{
+		{"Main menu", []key.Binding{
+			m.delegateKeys.choose,
+			m.delegateKeys.recent,
+			m.delegateKeys.scratch,
+			m.mainList.KeyMap.Filter,
+		}},
+		{"Todo list", []key.Binding{
+			m.delegateKeys.choose,
+			m.todoListKeys.newTodo,
+			m.todoListKeys.preview,
+			m.delegateKeys.remove,
+			m.todoListKeys.rename,
+			m.todoListKeys.duplicate,
+			m.todoListKeys.export,
+			m.todoListKeys.copy,
+			m.todoListKeys.copyText,
+			m.todoListKeys.archive,
+			m.todoListKeys.mark,
+			m.todoListKeys.bulkTrash,
+			m.todoListKeys.bulkArch,
+			m.todoListKeys.undoDelete,
+			m.todoListKeys.tag,
+			m.todoListKeys.sort,
+			m.todoListKeys.pin,
+			m.todoListKeys.label,
+			m.todoListKeys.byLabel,
+			m.todoListKeys.byDone,
+			m.todoListKeys.peek,
+			m.todoListKeys.peekDown,
+			m.todoListKeys.peekUp,
+			m.todoListKeys.parent,
+			m.todoListKeys.jump,
+			m.mainList.KeyMap.Filter,
+			m.todoListKeys.back,
+		}},
+		{"Trash", []key.Binding{
+			m.trashKeys.restore,
+			m.trashKeys.empty,
+			m.trashKeys.back,
+		}},
+		{"Archive", []key.Binding{
+			m.archiveKeys.restore,
+			m.archiveKeys.back,
+		}},
+		{"Editor", []key.Binding{
+			m.editorKeys.save,
+			m.editorKeys.saveExit,
+			m.editorKeys.preview,
+			m.editorKeys.toggleTask,
+			m.editorKeys.replace,
+			m.editorKeys.search,
+			m.editorKeys.gotoLine,
+			m.editorKeys.external,
+			m.editorKeys.undo,
+			m.editorKeys.redo,
+			m.editor.KeyMap.DeleteWordBackward,
+			m.editorKeys.wrap,
+			m.editorKeys.split,
+			m.editorKeys.date,
+			m.editorKeys.dateTime,
+			m.editorKeys.dupLine,
+			m.editorKeys.lineUp,
+			m.editorKeys.lineDown,
+			m.editorKeys.nextTask,
+			m.editorKeys.prevTask,
+			m.editorKeys.reflow,
+			m.editorKeys.table,
+			m.editorKeys.indent,
+			m.editorKeys.dedent,
+			m.editorKeys.zen,
+			m.editorKeys.rename,
+			m.editorKeys.diff,
+			m.editorKeys.readOnly,
+			m.editorKeys.reload,
+			m.editorKeys.cancel,
+		}},
+		{"Preview", []key.Binding{
+			m.previewKeys.scroll,
+			m.previewKeys.side,
+			m.previewKeys.topBottom,
+			m.previewKeys.halfPage,
+			m.previewKeys.theme,
+			m.previewKeys.lineNums,
+			m.previewKeys.raw,
+			m.previewKeys.wrap,
+			m.previewKeys.toc,
+			m.previewKeys.tocPrev,
+			m.previewKeys.tocNext,
+			m.previewKeys.lint,
+			m.previewKeys.openLink,
+			m.previewKeys.export,
+			m.previewKeys.copy,
+			m.previewKeys.copyText,
+			m.previewKeys.back,
+		}},
+		{"Global", []key.Binding{
+			m.globalKeys.help,
+			m.globalKeys.palette,
+			m.globalKeys.quit,
+		}},
+	}
+}
+
+func renderHelpSection(section helpSection) string {
+	var b strings.Builder
+	b.WriteString(helpSectionStyle.Render(section.title))
+	for _, binding := range section.bindings {
+		if !binding.Enabled() {
+			continue
+		}
+		h := binding.Help()
+		b.WriteString("\n" + helpKeyStyle.Render(h.Key) + helpDescStyle.Render(h.Desc))
+	}
+	return b.String()
+}
+
+// helpView renders every keybinding grouped by view, in two columns.
+func (m model) helpView() string {
+	sections := m.helpSections()
+	half := (len(sections) + 1) / 2
+
+	var left, right []string
+	for i, section := range sections {
+		if i < half {
+			left = append(left, renderHelpSection(section))
+		} else {
+			right = append(right, renderHelpSection(section))
+		}
+	}
+
+	columns := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		lipgloss.NewStyle().MarginRight(6).Render(strings.Join(left, "\n")),
+		strings.Join(right, "\n"),
+	)
+
+	appTitle := appTitleStyle.Render("Keybindings")
+	help := helpStyle.Render(m.globalKeys.help.Help().Key + "/esc: close help")
+	return appTitle + "\n" + columns + "\n" + help
+}
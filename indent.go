@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultTabWidth is how many spaces tab indents by unless
+// $GOTODO_TAB_WIDTH says otherwise.
+const defaultTabWidth = 4
+
+// tabWidthFromEnv returns the indent width set by $GOTODO_TAB_WIDTH.
+func tabWidthFromEnv() int {
+	width, err := strconv.Atoi(os.Getenv("GOTODO_TAB_WIDTH"))
+	if err != nil || width < 1 || width > 8 {
+		return defaultTabWidth
+	}
+	return width
+}
+
+// tabModeFromEnv checks $GOTODO_TAB_MODE, which may only be "spaces".
+// A literal tab mode can't be offered: the textarea sanitizes every tab
+// it's given into spaces, on load as well as when typed, and the
+// sanitizer can't be replaced from outside the bubbles package.
+func tabModeFromEnv() error {
+	switch mode := strings.ToLower(os.Getenv("GOTODO_TAB_MODE")); mode {
+	case "", "spaces":
+		return nil
+	case "tab", "tabs":
+		return fmt.Errorf("GOTODO_TAB_MODE=%s isn't supported, the editor turns tabs into spaces; indenting with spaces", mode)
+	default:
+		return fmt.Errorf("unknown GOTODO_TAB_MODE %q, indenting with spaces", mode)
+	}
+}
+
+// indent inserts one level of indentation at the cursor.
+func (m *model) indent() {
+	m.insertText(strings.Repeat(" ", m.tabWidth))
+}
+
+// dedent removes up to one level of indentation from the start of the
+// cursor line, keeping the cursor on the same text.
+func (m *model) dedent() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	row, col := m.editorCursor()
+	if row >= len(lines) {
+		return
+	}
+	line := lines[row]
+	n := 0
+	for n < m.tabWidth && n < len(line) && line[n] == ' ' {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	lines[row] = line[n:]
+	m.setEditorValue(strings.Join(lines, "\n"))
+	m.setEditorCursor(row, max(col-n, 0))
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// indexFileName is the file in the todo directory caching what each note
+// says about its tasks, tags and due date, so listing doesn't have to
+// read every note.
+const indexFileName = ".gotodo-index.json"
+
+// indexEntry is what the index remembers about a note, valid for as long
+// as its mod time and size stay the same.
+type indexEntry struct {
+	ModTime  time.Time `json:"modTime"`
+	Size     int64     `json:"size"`
+	Done     int       `json:"done"`
+	Total    int       `json:"total"`
+	Tags     []string  `json:"tags,omitempty"`
+	Due      time.Time `json:"due"`
+	Priority priority  `json:"priority,omitempty"`
+}
+
+// noteIndex caches indexEntries by filename for the notes in dir. It's
+// shared by loads running in the background, hence the lock. Encrypted
+// notes are never indexed, as that would leave their tags in the clear.
+type noteIndex struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]indexEntry
+	changed bool
+}
+
+// indexFromEnv reports whether $GOTODO_INDEX asks for the index.
+func indexFromEnv() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("GOTODO_INDEX"))
+	return enabled
+}
+
+// openIndex reads the index kept in dir. A missing or malformed file
+// starts an empty one.
+func openIndex(dir string) *noteIndex {
+	idx := &noteIndex{dir: dir, entries: make(map[string]indexEntry)}
+	data, err := os.ReadFile(filepath.Join(dir, indexFileName))
+	if err != nil {
+		return idx
+	}
+	if err := json.Unmarshal(data, &idx.entries); err != nil {
+		idx.entries = make(map[string]indexEntry)
+	}
+	return idx
+}
+
+// lookup fills in todo from the index, if its entry is still current for
+// info.
+func (idx *noteIndex) lookup(todo *todoItem, info os.FileInfo) bool {
+	if idx == nil || todo.encrypted {
+		return false
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	entry, ok := idx.entries[todo.filename]
+	if !ok || !entry.ModTime.Equal(info.ModTime()) || entry.Size != info.Size() {
+		return false
+	}
+	todo.done, todo.total = entry.Done, entry.Total
+	todo.tags = entry.Tags
+	todo.due = entry.Due
+	todo.priority = entry.Priority
+	return true
+}
+
+// store records what was just read from todo's note.
+func (idx *noteIndex) store(todo todoItem) {
+	if idx == nil || todo.encrypted {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.entries[todo.filename] = indexEntry{
+		ModTime:  todo.modified,
+		Size:     todo.size,
+		Done:     todo.done,
+		Total:    todo.total,
+		Tags:     todo.tags,
+		Due:      todo.due,
+		Priority: todo.priority,
+	}
+	idx.changed = true
+}
+
+// forget drops filename from the index.
+func (idx *noteIndex) forget(filename string) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.entries[filename]; ok {
+		delete(idx.entries, filename)
+		idx.changed = true
+	}
+}
+
+// prune drops the notes that weren't seen in a full listing.
+func (idx *noteIndex) prune(seen map[string]bool) {
+	if idx == nil {
+		return
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for filename := range idx.entries {
+		if !seen[filename] {
+			delete(idx.entries, filename)
+			idx.changed = true
+		}
+	}
+}
+
+// refresh re-reads filename, relative to the index's directory, after
+// it's been written.
+func (idx *noteIndex) refresh(filename string) {
+	if idx == nil || isEncrypted(filename) {
+		return
+	}
+	info, err := os.Stat(filepath.Join(idx.dir, filename))
+	if err != nil {
+		idx.forget(filename)
+		return
+	}
+	content, err := os.ReadFile(filepath.Join(idx.dir, filename))
+	if err != nil {
+		idx.forget(filename)
+		return
+	}
+	todo := todoItem{filename: filename, modified: info.ModTime(), size: info.Size()}
+	todo.done, todo.total = countCheckboxes(string(content))
+	todo.tags = extractTags(string(content))
+	todo.due = parseDue(string(content))
+	todo.priority = parsePriority(string(content))
+	idx.store(todo)
+}
+
+// saveIndex writes the index after a note was changed from the app.
+func (m *model) saveIndex() {
+	if err := m.index.save(); err != nil {
+		logOp("save index", indexFileName, err)
+	}
+}
+
+// save writes the index back to disk if anything changed since it was
+// last written.
+func (idx *noteIndex) save() error {
+	if idx == nil {
+		return nil
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if !idx.changed {
+		return nil
+	}
+	data, err := json.Marshal(idx.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(idx.dir, indexFileName), data, 0644); err != nil {
+		return err
+	}
+	idx.changed = false
+	return nil
+}
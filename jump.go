@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// numberedItem shows a list item with its position in front, while the
+// todo list is taking a number to jump to.
+type numberedItem struct {
+	list.DefaultItem
+	n int
+}
+
+func (i numberedItem) Title() string {
+	return fmt.Sprintf("%d. %s", i.n, i.DefaultItem.Title())
+}
+
+// setJumpNumbers shows or hides the item numbers in the todo list.
+func (m *model) setJumpNumbers(on bool) {
+	delegate := newTodoDelegate()
+	delegate.numbered = on
+	m.todoList.SetDelegate(delegate)
+}
+
+// handleJump takes digits typed in the todo list as the number of an
+// item to jump to, numbering the items while one is being typed. Enter
+// jumps, backspace takes back a digit and esc gives up. It reports
+// whether msg was used; any other key leaves the jump behind.
+func (m *model) handleJump(msg tea.KeyMsg) (tea.Cmd, bool) {
+	key := msg.String()
+	if len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+		if m.jumpDigits == "" {
+			m.setJumpNumbers(true)
+		}
+		m.jumpDigits += key
+		return m.listStatus(&m.todoList, statusInfo, "Jump to "+m.jumpDigits+" (enter to jump, esc to cancel)"), true
+	}
+	if m.jumpDigits == "" {
+		return nil, false
+	}
+
+	switch key {
+	case "backspace":
+		m.jumpDigits = m.jumpDigits[:len(m.jumpDigits)-1]
+		if m.jumpDigits == "" {
+			m.setJumpNumbers(false)
+			return nil, true
+		}
+		return m.listStatus(&m.todoList, statusInfo, "Jump to "+m.jumpDigits+" (enter to jump, esc to cancel)"), true
+	case "enter":
+		return m.jump(), true
+	case "esc":
+		m.jumpDigits = ""
+		m.setJumpNumbers(false)
+		return nil, true
+	}
+	m.jumpDigits = ""
+	m.setJumpNumbers(false)
+	return nil, false
+}
+
+// jump selects the item numbered by the digits typed, or the nearest
+// one there is.
+func (m *model) jump() tea.Cmd {
+	n, err := strconv.Atoi(m.jumpDigits)
+	m.jumpDigits = ""
+	m.setJumpNumbers(false)
+
+	count := len(m.todoList.VisibleItems())
+	switch {
+	case count == 0:
+		return m.listStatus(&m.todoList, statusInfo, "Nothing to jump to")
+	case err != nil || n > count:
+		m.todoList.Select(count - 1)
+		return m.listStatus(&m.todoList, statusInfo, fmt.Sprintf("Only %d listed, jumped to the last", count))
+	case n < 1:
+		m.todoList.Select(0)
+		return m.listStatus(&m.todoList, statusInfo, "Numbers start at 1, jumped to the first")
+	}
+	m.todoList.Select(n - 1)
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyConfigPath returns where user key bindings are read from, normally
+// ~/.config/gotodo/keys.json.
+func keyConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotodo", "keys.json"), nil
+}
+
+// keyList is one or more key strings. The config accepts either a single
+// "ctrl+s" or a list like ["ctrl+s", "f2"].
+type keyList []string
+
+func (k *keyList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*k = keyList{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("want a key or a list of keys, got %s", data)
+	}
+	*k = many
+	return nil
+}
+
+// keyBindings names every remappable binding, as used in the config file.
+func (m *model) keyBindings() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"menu.recent":  &m.delegateKeys.recent,
+		"menu.scratch": &m.delegateKeys.scratch,
+
+		// Opening is shared by the main menu and the todo list
+		"list.open":       &m.delegateKeys.choose,
+		"list.delete":     &m.delegateKeys.remove,
+		"list.back":       &m.todoListKeys.back,
+		"list.preview":    &m.todoListKeys.preview,
+		"list.rename":     &m.todoListKeys.rename,
+		"list.duplicate":  &m.todoListKeys.duplicate,
+		"list.export":     &m.todoListKeys.export,
+		"list.tag":        &m.todoListKeys.tag,
+		"list.sort":       &m.todoListKeys.sort,
+		"list.newTodo":    &m.todoListKeys.newTodo,
+		"list.pin":        &m.todoListKeys.pin,
+		"list.label":      &m.todoListKeys.label,
+		"list.byLabel":    &m.todoListKeys.byLabel,
+		"list.byDone":     &m.todoListKeys.byDone,
+		"list.peek":       &m.todoListKeys.peek,
+		"list.peekDown":   &m.todoListKeys.peekDown,
+		"list.peekUp":     &m.todoListKeys.peekUp,
+		"list.parent":     &m.todoListKeys.parent,
+		"list.copy":       &m.todoListKeys.copy,
+		"list.copyText":   &m.todoListKeys.copyText,
+		"list.archive":    &m.todoListKeys.archive,
+		"list.mark":       &m.todoListKeys.mark,
+		"list.bulkTrash":  &m.todoListKeys.bulkTrash,
+		"list.bulkArch":   &m.todoListKeys.bulkArch,
+		"list.undoDelete": &m.todoListKeys.undoDelete,
+
+		"editor.preview":    &m.editorKeys.preview,
+		"editor.toggleTask": &m.editorKeys.toggleTask,
+		"editor.replace":    &m.editorKeys.replace,
+		"editor.search":     &m.editorKeys.search,
+		"editor.gotoLine":   &m.editorKeys.gotoLine,
+		"editor.external":   &m.editorKeys.external,
+		"editor.undo":       &m.editorKeys.undo,
+		"editor.redo":       &m.editorKeys.redo,
+		"editor.wrap":       &m.editorKeys.wrap,
+		"editor.split":      &m.editorKeys.split,
+		"editor.date":       &m.editorKeys.date,
+		"editor.dateTime":   &m.editorKeys.dateTime,
+		"editor.dupLine":    &m.editorKeys.dupLine,
+		"editor.lineUp":     &m.editorKeys.lineUp,
+		"editor.lineDown":   &m.editorKeys.lineDown,
+		"editor.nextTask":   &m.editorKeys.nextTask,
+		"editor.prevTask":   &m.editorKeys.prevTask,
+		"editor.reflow":     &m.editorKeys.reflow,
+		"editor.table":      &m.editorKeys.table,
+		"editor.indent":     &m.editorKeys.indent,
+		"editor.dedent":     &m.editorKeys.dedent,
+		"editor.readOnly":   &m.editorKeys.readOnly,
+		"editor.reload":     &m.editorKeys.reload,
+		"editor.zen":        &m.editorKeys.zen,
+		"editor.rename":     &m.editorKeys.rename,
+		"editor.diff":       &m.editorKeys.diff,
+		"editor.cancel":     &m.editorKeys.cancel,
+		"editor.saveExit":   &m.editorKeys.saveExit,
+		"editor.save":       &m.editorKeys.save,
+		"editor.deleteWord": &m.editor.KeyMap.DeleteWordBackward,
+
+		"trash.restore": &m.trashKeys.restore,
+		"trash.empty":   &m.trashKeys.empty,
+		"trash.back":    &m.trashKeys.back,
+
+		"archive.restore": &m.archiveKeys.restore,
+		"archive.back":    &m.archiveKeys.back,
+
+		"preview.theme":    &m.previewKeys.theme,
+		"preview.lineNums": &m.previewKeys.lineNums,
+		"preview.raw":      &m.previewKeys.raw,
+		"preview.wrap":     &m.previewKeys.wrap,
+		"preview.toc":      &m.previewKeys.toc,
+		"preview.tocPrev":  &m.previewKeys.tocPrev,
+		"preview.tocNext":  &m.previewKeys.tocNext,
+		"preview.lint":     &m.previewKeys.lint,
+		"preview.openLink": &m.previewKeys.openLink,
+		"preview.export":   &m.previewKeys.export,
+		"preview.copy":     &m.previewKeys.copy,
+		"preview.copyText": &m.previewKeys.copyText,
+		"preview.back":     &m.previewKeys.back,
+
+		"global.help":    &m.globalKeys.help,
+		"global.palette": &m.globalKeys.palette,
+		"global.quit":    &m.globalKeys.quit,
+	}
+}
+
+// loadKeyConfig applies the user's key bindings over the defaults. A
+// missing file is fine; the returned warnings list entries that were
+// skipped.
+func (m *model) loadKeyConfig(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config map[string]keyList
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Apply in a stable order so warnings come out the same every time
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	bindings := m.keyBindings()
+	for _, name := range names {
+		binding, ok := bindings[name]
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("unknown key binding %q in %s", name, path))
+			continue
+		}
+		keys := config[name]
+		if len(keys) == 0 {
+			warnings = append(warnings, fmt.Sprintf("no keys given for %q in %s", name, path))
+			continue
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(strings.Join(keys, "/"), binding.Help().Desc)
+	}
+	return warnings, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// labelNames are the color labels a note can carry, in the order the
+// label key cycles through them.
+var labelNames = []string{"red", "yellow", "green", "blue"}
+
+var labelColors = map[string]lipgloss.Color{
+	"red":    lipgloss.Color("203"),
+	"yellow": lipgloss.Color("221"),
+	"green":  lipgloss.Color("114"),
+	"blue":   lipgloss.Color("75"),
+}
+
+// nextLabel returns the label after label, going back to none after the
+// last one.
+func nextLabel(label string) string {
+	for i, name := range labelNames {
+		if name == label && i+1 < len(labelNames) {
+			return labelNames[i+1]
+		}
+	}
+	if label == "" {
+		return labelNames[0]
+	}
+	return ""
+}
+
+// cycleLabel moves filename on to its next label and returns it.
+func (m *model) cycleLabel(filename string) string {
+	label := nextLabel(m.labels[filename])
+	if label == "" {
+		delete(m.labels, filename)
+		return ""
+	}
+	if m.labels == nil {
+		m.labels = make(map[string]string)
+	}
+	m.labels[filename] = label
+	return label
+}
+
+// filterByLabel keeps only the items labelled label; an empty label
+// keeps everything.
+func filterByLabel(items []list.Item, label string) []list.Item {
+	if label == "" {
+		return items
+	}
+	filtered := []list.Item{}
+	for _, it := range items {
+		if it.(todoItem).label == label {
+			filtered = append(filtered, it)
+		}
+	}
+	return filtered
+}
+
+// existingLabels returns the labels of notes that are still in the todo
+// directory, so deleted notes don't linger in the saved state.
+func (m model) existingLabels() map[string]string {
+	labels := make(map[string]string)
+	for filename, label := range m.labels {
+		if _, err := os.Stat(filepath.Join(m.todoDir, filename)); err == nil {
+			labels[filename] = label
+		}
+	}
+	return labels
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// urlPattern matches an http(s) URL, stopping before trailing punctuation
+// that's more likely to end the sentence than the link.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>()\[\]"']*[^\s<>()\[\]"'.,;:!?]`)
+
+// errNoOpener means there's no browser to hand a link to, like over SSH.
+var errNoOpener = errors.New("no browser available")
+
+// linkOpenedMsg reports how opening url in the browser went.
+type linkOpenedMsg struct {
+	url string
+	err error
+}
+
+// visibleLinks returns the URLs on the lines the preview is showing, in
+// order.
+func (m model) visibleLinks() []string {
+	lines := strings.Split(ansi.Strip(m.viewport.View()), "\n")
+	var links []string
+	for _, line := range lines {
+		links = append(links, urlPattern.FindAllString(line, -1)...)
+	}
+	return links
+}
+
+// openerCommand returns the command that opens url with the desktop's
+// default handler.
+func openerCommand(url string) (*exec.Cmd, error) {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return nil, errNoOpener
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url), nil
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url), nil
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return nil, errNoOpener
+		}
+		return exec.Command("xdg-open", url), nil
+	}
+}
+
+// openLink opens url in the browser in the background.
+func openLink(url string) tea.Cmd {
+	return func() tea.Msg {
+		c, err := openerCommand(url)
+		if err == nil {
+			err = c.Run()
+		}
+		return linkOpenedMsg{url: url, err: err}
+	}
+}
+
+// openNextLink opens the next link in view, going back to the first once
+// each has been opened or the preview has scrolled.
+func (m *model) openNextLink() tea.Cmd {
+	links := m.visibleLinks()
+	if len(links) == 0 {
+		m.previewStatus = "No links in view"
+		return nil
+	}
+	if m.linkOffset != m.viewport.YOffset || m.linkIndex >= len(links) {
+		m.linkOffset = m.viewport.YOffset
+		m.linkIndex = 0
+	}
+	url := links[m.linkIndex]
+	m.linkIndex++
+
+	m.previewStatus = "Opening " + url
+	if len(links) > 1 {
+		m.previewStatus += fmt.Sprintf(" (%d of %d in view)", m.linkIndex, len(links))
+	}
+	return openLink(url)
+}
+
+// linkOpenedStatus describes how opening a link went, copying it to the
+// clipboard instead when there's no browser to open it in.
+func linkOpenedStatus(msg linkOpenedMsg) string {
+	if msg.err == nil {
+		return "Opened " + msg.url
+	}
+	if err := clipboard.WriteAll(msg.url); err != nil {
+		return fmt.Sprintf("Couldn't open %s: %v", msg.url, msg.err)
+	}
+	return "Couldn't open a browser, copied " + msg.url
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// lintMaxLines is how many warnings the preview's panel lists before
+// summarising the rest.
+const lintMaxLines = 5
+
+var lintStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), true, false, false, false).
+	BorderForeground(lipgloss.Color("238")).
+	Foreground(lipgloss.Color("221"))
+
+var (
+	// emptyHeadingPattern matches a heading with nothing after its #s.
+	emptyHeadingPattern = regexp.MustCompile(`^ {0,3}#{1,6}\s*$`)
+
+	// emptyLinkPattern matches a link or image with no target.
+	emptyLinkPattern = regexp.MustCompile(`\[[^\]]*\]\(\s*\)`)
+
+	// badCheckboxPattern matches a list item whose task box isn't "[ ]"
+	// or "[x]", so it won't be toggled or counted.
+	badCheckboxPattern = regexp.MustCompile(`^\s*[-*+]\s+\[(|\s{2,}|[^\]\sxX])\](\s|$)`)
+)
+
+// lintWarning is a problem found in a note, on a 1-based line of the
+// source as it appears in the editor.
+type lintWarning struct {
+	line int
+	msg  string
+}
+
+func (w lintWarning) String() string {
+	return fmt.Sprintf("line %d: %s", w.line, w.msg)
+}
+
+// lintMarkdown checks content against a handful of rules for markdown
+// that renders differently from how it was probably meant. Frontmatter
+// and the insides of code blocks are left alone.
+func lintMarkdown(content string) []lintWarning {
+	lines := strings.Split(content, "\n")
+	start := 0
+	if fm, _, ok := splitFrontmatter(content); ok {
+		start = len(fm) + 2
+	}
+
+	var warnings []lintWarning
+	fence, fenceLine := "", 0
+	level := 0
+	for i := start; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			switch fence {
+			case "":
+				fence, fenceLine = match[1], i+1
+			case match[1]:
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		if emptyHeadingPattern.MatchString(line) {
+			warnings = append(warnings, lintWarning{i + 1, "heading has no text"})
+		} else if match := headingPattern.FindStringSubmatch(line); match != nil {
+			next := len(match[1])
+			if level > 0 && next > level+1 {
+				warnings = append(warnings, lintWarning{i + 1, fmt.Sprintf("heading skips from h%d to h%d", level, next)})
+			}
+			level = next
+		}
+		if emptyLinkPattern.MatchString(line) {
+			warnings = append(warnings, lintWarning{i + 1, "link has no target"})
+		}
+		if badCheckboxPattern.MatchString(line) {
+			warnings = append(warnings, lintWarning{i + 1, "task box should be [ ] or [x]"})
+		}
+	}
+	if fence != "" {
+		warnings = append(warnings, lintWarning{fenceLine, "code block is never closed"})
+	}
+	return warnings
+}
+
+// noteWarnings lints the note in the editor, if it's markdown.
+func (m model) noteWarnings() []lintWarning {
+	if !isMarkdown(m.currentFileName()) {
+		return nil
+	}
+	return lintMarkdown(m.editor.Value())
+}
+
+// lintSummary is the warning count shown in the preview footer.
+func (m model) lintSummary() string {
+	switch len(m.lint) {
+	case 0:
+		return "no warnings"
+	case 1:
+		return "1 warning"
+	default:
+		return fmt.Sprintf("%d warnings", len(m.lint))
+	}
+}
+
+// lintView renders the warnings panel under the preview, or nothing while
+// it's collapsed.
+func (m model) lintView() string {
+	if !m.lintVisible {
+		return ""
+	}
+	width := m.viewport.Width
+	var lines []string
+	if len(m.lint) == 0 {
+		lines = append(lines, lineNumberStyle.Render("No warnings"))
+	}
+	for i, w := range m.lint {
+		if i == lintMaxLines {
+			lines = append(lines, lineNumberStyle.Render(fmt.Sprintf("… and %d more", len(m.lint)-i)))
+			break
+		}
+		lines = append(lines, ansi.Truncate("⚠ "+w.String(), width, "…"))
+	}
+	return lintStyle.Width(width).Render(strings.Join(lines, "\n"))
+}
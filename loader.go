@@ -0,0 +1,239 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Reading every note can take a while in a big todo directory, so the
+// lists that need to are filled by commands that report back with these
+// messages rather than from inside Update.
+
+// todosLoadedMsg carries the notes read for the todo list. id matches
+// todosLoadID unless a newer load has started since.
+type todosLoadedMsg struct {
+	id      int
+	items   []list.Item
+	folders []string
+	err     error
+}
+
+// todayLoadedMsg carries the tasks gathered for the today view.
+type todayLoadedMsg struct {
+	tasks []list.Item
+	err   error
+}
+
+// statsLoadedMsg carries the notes read for the statistics dashboard.
+type statsLoadedMsg struct {
+	stats todoStats
+	err   error
+}
+
+// readNoteFile reads filename, relative to dir, decrypting it with
+// cipher if it's encrypted.
+func readNoteFile(dir string, cipher *noteCipher, filename string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filename))
+	if err != nil || !isEncrypted(filename) {
+		return data, err
+	}
+	return cipher.decrypt(data)
+}
+
+// loadTodoFiles lists the notes in dir with what they say about their
+// tasks, tags and due date, but not the pins, marks and labels kept by
+// the model. Notes the index has current entries for aren't read again;
+// index may be nil. An error means the directory itself couldn't be
+// created or read, which shouldn't be mistaken for it being empty.
+func loadTodoFiles(dir string, cipher *noteCipher, index *noteIndex) ([]list.Item, error) {
+	// Create directory if it doesn't exist
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return []list.Item{}, err
+	}
+
+	// Walk subfolders too, listing notes by their path relative to the
+	// todo directory. Hidden folders hold app data and are skipped, as is
+	// the archive.
+	archiveDir := filepath.Join(dir, archiveDirName)
+	var items []list.Item
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, file fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if file.IsDir() {
+			if path != dir && (strings.HasPrefix(file.Name(), ".") || path == archiveDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isNoteFile(file.Name()) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+
+		todo := todoItem{filename: filepath.ToSlash(relPath), encrypted: isEncrypted(file.Name())}
+		seen[todo.filename] = true
+		fileInfo, err := file.Info()
+		if err == nil {
+			todo.modTime = "Modified: " + fileInfo.ModTime().Format("Jan 02, 2006 3:04 PM")
+			todo.modified = fileInfo.ModTime()
+			todo.size = fileInfo.Size()
+			if index.lookup(&todo, fileInfo) {
+				items = append(items, todo)
+				return nil
+			}
+		}
+		if content, err := readNoteFile(dir, cipher, todo.filename); err == nil {
+			todo.done, todo.total = countCheckboxes(string(content))
+			todo.tags = extractTags(string(content))
+			todo.due = parseDue(string(content))
+			todo.priority = parsePriority(string(content))
+			if fileInfo != nil {
+				index.store(todo)
+			}
+		}
+
+		items = append(items, todo)
+		return nil
+	})
+	if err != nil {
+		return []list.Item{}, err
+	}
+	index.prune(seen)
+	if err := index.save(); err != nil {
+		logOp("save index", indexFileName, err)
+	}
+	return items, nil
+}
+
+// loadTodayTasks collects the open tasks of notes in dir due or tagged
+// today, plus any task tagged #today wherever it is.
+func loadTodayTasks(dir string, cipher *noteCipher, index *noteIndex) ([]list.Item, error) {
+	now := time.Now()
+	tasks := []list.Item{}
+	items, err := loadTodoFiles(dir, cipher, index)
+	for _, it := range items {
+		todo := it.(todoItem)
+		dueToday := !todo.due.IsZero() && sameDay(todo.due, now)
+		if !dueToday && !hasTag(todo.tags, todayTag) {
+			continue
+		}
+		content, err := readNoteFile(dir, cipher, todo.filename)
+		if err != nil {
+			continue
+		}
+		all := dueToday || noteTagged(string(content), todayTag)
+		tasks = append(tasks, openTasks(todo.filename, string(content), all)...)
+	}
+	return tasks, err
+}
+
+// reloadTodoList reads the notes for the todo list again in the
+// background. The selection is kept, unless selectTodo picks another
+// note before they arrive.
+func (m *model) reloadTodoList() tea.Cmd {
+	if !m.todosLoading {
+		switch selected := m.todoList.SelectedItem().(type) {
+		case todoItem:
+			m.pendingSelect = selected.filename
+		case folderItem:
+			m.pendingSelect = selected.path
+		}
+	}
+	m.todosLoading = true
+	m.todosLoadID++
+	id, dir, cipher, index := m.todosLoadID, m.todoDir, m.cipher, m.index
+	return tea.Batch(m.todoList.StartSpinner(), func() tea.Msg {
+		items, err := loadTodoFiles(dir, cipher, index)
+		return todosLoadedMsg{id: id, items: items, folders: loadFolders(dir), err: err}
+	})
+}
+
+// todosLoaded fills the todo list with freshly read notes.
+func (m *model) todosLoaded(msg todosLoadedMsg) tea.Cmd {
+	if msg.id != m.todosLoadID {
+		return nil
+	}
+	m.todosLoading = false
+	m.todoList.StopSpinner()
+	m.loadErr = msg.err
+	m.todoTotal = len(msg.items)
+	items := m.todoListItems(msg.items)
+	cmd := m.todoList.SetItems(append(folderItems(msg.folders, msg.items, m.todoFolder), items...))
+	m.todoList.Title = m.todoListTitle()
+	m.sizeTodoList()
+	m.selectTodo(m.pendingSelect)
+	m.pendingSelect = ""
+
+	// Say why a filtered list came up empty
+	if len(items) > 0 || msg.err != nil {
+		return cmd
+	}
+	var status string
+	switch {
+	case m.tagFilter != "" && m.labelFilter != "":
+		status = "No notes tagged #" + m.tagFilter + " and labelled " + m.labelFilter
+	case m.tagFilter != "":
+		status = "No notes tagged #" + m.tagFilter
+	case m.labelFilter != "":
+		status = "No notes labelled " + m.labelFilter
+	case m.completion == showOpenTasks:
+		status = "No notes with open tasks"
+	case m.completion == showComplete:
+		status = "No complete notes"
+	default:
+		return cmd
+	}
+	return tea.Batch(cmd, m.listStatus(&m.todoList, statusInfo, status))
+}
+
+// todoListItems applies the pins, marks, labels, edited badges, sort
+// order and filters of the todo list to freshly read notes, keeping those
+// in the folder it's showing.
+func (m *model) todoListItems(items []list.Item) []list.Item {
+	now := time.Now()
+	for i, it := range items {
+		todo := it.(todoItem)
+		todo.pinned = m.pinned[todo.filename]
+		todo.marked = m.marked[todo.filename]
+		todo.label = m.labels[todo.filename]
+		todo.edited = recentlyEdited(todo.modified, now, m.editedWindow)
+		items[i] = todo
+	}
+	items = inFolder(items, m.todoFolder)
+	sortTodoItems(items, m.sortMode)
+	return filterByCompletion(filterByLabel(filterByTag(items, m.tagFilter), m.labelFilter), m.completion)
+}
+
+// loadToday gathers today's tasks in the background.
+func (m *model) loadToday() tea.Cmd {
+	dir, cipher, index := m.todoDir, m.cipher, m.index
+	return func() tea.Msg {
+		tasks, err := loadTodayTasks(dir, cipher, index)
+		return todayLoadedMsg{tasks: tasks, err: err}
+	}
+}
+
+// loadStats reads every note for the statistics dashboard in the
+// background.
+func (m *model) loadStats() tea.Cmd {
+	dir, cipher, index := m.todoDir, m.cipher, m.index
+	return func() tea.Msg {
+		items, err := loadTodoFiles(dir, cipher, index)
+		return statsLoadedMsg{stats: computeStats(items), err: err}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var viewStateNames = [...]string{
+	listView:              "menu",
+	createTodoView:        "create",
+	editorView:            "editor",
+	previewView:           "preview",
+	todoListView:          "todo list",
+	trashView:             "trash",
+	confirmEmptyTrashView: "confirm empty trash",
+	renameTodoView:        "rename",
+	confirmDiscardView:    "confirm discard",
+	helpView:              "help",
+	replaceView:           "replace",
+	gotoLineView:          "go to line",
+	statsView:             "statistics",
+	tagFilterView:         "tag filter",
+	paletteView:           "palette",
+	recentView:            "recent",
+	todayView:             "today",
+	templateView:          "templates",
+	archiveView:           "archive",
+	confirmBulkView:       "confirm bulk",
+	searchView:            "search",
+	exportAllView:         "export all",
+	confirmExistingView:   "confirm existing",
+	confirmOverwriteView:  "confirm overwrite",
+	renameNoteView:        "rename note",
+	diffView:              "diff",
+	workspaceView:         "workspaces",
+	exportingView:         "exporting",
+	confirmReloadView:     "confirm reload",
+	confirmQuitView:       "confirm quit",
+	renameTagView:         "rename tag",
+}
+
+func (v viewState) String() string {
+	if int(v) < len(viewStateNames) && viewStateNames[v] != "" {
+		return viewStateNames[v]
+	}
+	return fmt.Sprintf("view %d", int(v))
+}
+
+// startLog sends the standard logger to $GOTODO_LOG. When that's a
+// directory, each session gets a new file in it; otherwise sessions are
+// appended to the file it names. Logging is off when it's unset, and the
+// logger is silenced so nothing can land on top of the TUI.
+func startLog() (io.Closer, error) {
+	path := os.Getenv("GOTODO_LOG")
+	if path == "" {
+		log.SetOutput(io.Discard)
+		return nil, nil
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "gotodo-"+time.Now().Format("20060102-150405")+".log")
+	}
+	f, err := tea.LogToFile(path, "gotodo")
+	if err != nil {
+		log.SetOutput(io.Discard)
+		return nil, err
+	}
+	return f, nil
+}
+
+// logOp logs a file operation on name and whether it worked.
+func logOp(op, name string, err error) {
+	if err != nil {
+		log.Printf("%s %s failed: %v", op, name, err)
+		return
+	}
+	log.Printf("%s %s", op, name)
+}
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -74,6 +84,15 @@ var (
 	statusMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
+
+	errorMessageStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#E0245E", Dark: "#FF5F87"})
+
+	dirtyMarkerStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#B58900", Dark: "#FFD75F"})
+
+	readOnlyStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.AdaptiveColor{Light: "#6C71C4", Dark: "#AF87FF"})
 )
 
 type item struct {
@@ -85,14 +104,140 @@ func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
 
 type todoItem struct {
-	filename string
-	modTime  string
+	filename  string
+	modTime   string
+	modified  time.Time
+	size      int64
+	done      int
+	total     int
+	tags      []string
+	pinned    bool
+	marked    bool
+	due       time.Time
+	priority  priority
+	encrypted bool
+	label     string
+	// edited is set when the note changed within the edited window
+	edited bool
+}
+
+func (i todoItem) Title() string {
+	title := i.priority.marker() + i.filename
+	if i.encrypted {
+		title = "🔒 " + title
+	}
+	if i.pinned {
+		title = "★ " + title
+	}
+	if i.marked {
+		title = "✓ " + title
+	}
+	if i.edited {
+		title += " " + editedBadgeStyle.Render("NEW")
+	}
+	return title
+}
+
+func (i todoItem) Description() string {
+	desc := i.modTime
+	if i.total > 0 {
+		desc += fmt.Sprintf(" · %d/%d done", i.done, i.total)
+	}
+	if i.total > 0 && i.done == i.total {
+		desc += " · all done, a to archive"
+	}
+	if !i.due.IsZero() {
+		desc += " · due " + i.due.Format("Jan 02, 2006")
+		if isOverdue(i.due, time.Now()) {
+			desc += " (overdue)"
+		}
+	}
+	if len(i.tags) > 0 {
+		desc += " · #" + strings.Join(i.tags, " #")
+	}
+	return desc
 }
 
-func (i todoItem) Title() string       { return i.filename }
-func (i todoItem) Description() string { return i.modTime }
 func (i todoItem) FilterValue() string { return i.filename }
 
+type sortMode int
+
+const (
+	sortByName sortMode = iota
+	sortByModTime
+	sortBySize
+	sortByDue
+	sortByPriority
+)
+
+func (s sortMode) String() string {
+	switch s {
+	case sortByModTime:
+		return "last modified"
+	case sortBySize:
+		return "size"
+	case sortByDue:
+		return "due date"
+	case sortByPriority:
+		return "priority"
+	default:
+		return "name"
+	}
+}
+
+// next cycles through the available sort modes.
+func (s sortMode) next() sortMode {
+	return (s + 1) % (sortByPriority + 1)
+}
+
+// filterByTag keeps the todo items tagged with tag. An empty tag keeps
+// everything.
+func filterByTag(items []list.Item, tag string) []list.Item {
+	if tag == "" {
+		return items
+	}
+	filtered := []list.Item{}
+	for _, it := range items {
+		if hasTag(it.(todoItem).tags, tag) {
+			filtered = append(filtered, it)
+		}
+	}
+	return filtered
+}
+
+// sortTodoItems orders todo items in place: names ascending, mod times
+// and sizes descending, due dates soonest first with undated notes last,
+// and priorities highest first, then by due date, with notes without one
+// last. Pinned items stay on top whatever the mode, below any folders.
+func sortTodoItems(items []list.Item, mode sortMode) {
+	sort.SliceStable(items, func(i, j int) bool {
+		fa, aFolder := items[i].(folderItem)
+		fb, bFolder := items[j].(folderItem)
+		if aFolder || bFolder {
+			return aFolder && (!bFolder || fa.path < fb.path)
+		}
+		a, b := items[i].(todoItem), items[j].(todoItem)
+		if a.pinned != b.pinned {
+			return a.pinned
+		}
+		switch mode {
+		case sortByModTime:
+			return a.modified.After(b.modified)
+		case sortBySize:
+			return a.size > b.size
+		case sortByDue:
+			return dueBefore(a.due, b.due)
+		case sortByPriority:
+			if a.priority != b.priority {
+				return a.priority > b.priority
+			}
+			return dueBefore(a.due, b.due)
+		default:
+			return a.filename < b.filename
+		}
+	})
+}
+
 type viewState int
 
 const (
@@ -101,11 +246,39 @@ const (
 	editorView
 	previewView
 	todoListView
+	trashView
+	confirmEmptyTrashView
+	renameTodoView
+	confirmDiscardView
+	helpView
+	replaceView
+	gotoLineView
+	statsView
+	tagFilterView
+	paletteView
+	recentView
+	todayView
+	templateView
+	archiveView
+	confirmBulkView
+	searchView
+	exportAllView
+	confirmExistingView
+	confirmOverwriteView
+	renameNoteView
+	diffView
+	workspaceView
+	exportingView
+	confirmReloadView
+	confirmQuitView
+	renameTagView
 )
 
 type delegateKeyMap struct {
-	choose key.Binding
-	remove key.Binding
+	choose  key.Binding
+	remove  key.Binding
+	recent  key.Binding
+	scratch key.Binding
 }
 
 func newDelegateKeyMap() *delegateKeyMap {
@@ -116,13 +289,45 @@ func newDelegateKeyMap() *delegateKeyMap {
 		),
 		remove: key.NewBinding(
 			key.WithKeys("x", "backspace"),
-			key.WithHelp("x", "delete"),
+			key.WithHelp("x", "move to trash"),
+		),
+		recent: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "recent files"),
+		),
+		scratch: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "scratchpad"),
 		),
 	}
 }
 
 type todoListKeyMap struct {
-	back key.Binding
+	back      key.Binding
+	newTodo   key.Binding
+	preview   key.Binding
+	rename    key.Binding
+	duplicate key.Binding
+	export    key.Binding
+	tag       key.Binding
+	sort      key.Binding
+	pin       key.Binding
+	copy      key.Binding
+	copyText  key.Binding
+	archive   key.Binding
+	mark      key.Binding
+	bulkTrash key.Binding
+	bulkArch  key.Binding
+	label     key.Binding
+	byLabel   key.Binding
+	byDone    key.Binding
+	peek      key.Binding
+	peekDown  key.Binding
+	peekUp    key.Binding
+	parent    key.Binding
+	jump      key.Binding
+	// undoDelete only applies briefly after a delete
+	undoDelete key.Binding
 }
 
 func newTodoListKeyMap() *todoListKeyMap {
@@ -131,22 +336,659 @@ func newTodoListKeyMap() *todoListKeyMap {
 			key.WithKeys("esc"),
 			key.WithHelp("esc", "back"),
 		),
+		newTodo: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "new todo"),
+		),
+		preview: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "preview"),
+		),
+		rename: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "rename"),
+		),
+		duplicate: key.NewBinding(
+			key.WithKeys("ctrl+n"),
+			key.WithHelp("ctrl+n", "duplicate"),
+		),
+		export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export html"),
+		),
+		tag: key.NewBinding(
+			key.WithKeys("#"),
+			key.WithHelp("#", "filter by tag"),
+		),
+		sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "sort"),
+		),
+		pin: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "pin"),
+		),
+		copy: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy markdown"),
+		),
+		copyText: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy as text"),
+		),
+		archive: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "archive"),
+		),
+		mark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "mark"),
+		),
+		bulkTrash: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "trash marked"),
+		),
+		bulkArch: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "archive marked"),
+		),
+		label: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "color label"),
+		),
+		byLabel: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "filter by label"),
+		),
+		byDone: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open/complete"),
+		),
+		peek: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "side preview"),
+		),
+		peekDown: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "scroll preview down"),
+		),
+		peekUp: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "scroll preview up"),
+		),
+		parent: key.NewBinding(
+			key.WithKeys("-"),
+			key.WithHelp("-", "up a folder"),
+		),
+		jump: key.NewBinding(
+			key.WithKeys("0", "1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("0-9 enter", "jump to number"),
+		),
+		undoDelete: key.NewBinding(
+			key.WithKeys("u"),
+			key.WithHelp("u", "undo delete"),
+		),
+	}
+}
+
+type editorKeyMap struct {
+	preview    key.Binding
+	toggleTask key.Binding
+	replace    key.Binding
+	search     key.Binding
+	gotoLine   key.Binding
+	external   key.Binding
+	undo       key.Binding
+	redo       key.Binding
+	wrap       key.Binding
+	split      key.Binding
+	date       key.Binding
+	dateTime   key.Binding
+	nextTask   key.Binding
+	dupLine    key.Binding
+	lineUp     key.Binding
+	lineDown   key.Binding
+	prevTask   key.Binding
+	reflow     key.Binding
+	table      key.Binding
+	indent     key.Binding
+	dedent     key.Binding
+	zen        key.Binding
+	rename     key.Binding
+	diff       key.Binding
+	readOnly   key.Binding
+	reload     key.Binding
+	cancel     key.Binding
+	saveExit   key.Binding
+	save       key.Binding
+}
+
+func newEditorKeyMap() *editorKeyMap {
+	return &editorKeyMap{
+		preview: key.NewBinding(
+			key.WithKeys("ctrl+p"),
+			key.WithHelp("ctrl+p", "preview"),
+		),
+		toggleTask: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "toggle task"),
+		),
+		replace: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "find & replace"),
+		),
+		search: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "search"),
+		),
+		gotoLine: key.NewBinding(
+			key.WithKeys("ctrl+g"),
+			key.WithHelp("ctrl+g", "go to line"),
+		),
+		external: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+e", "open in $EDITOR"),
+		),
+		undo: key.NewBinding(
+			key.WithKeys("ctrl+z"),
+			key.WithHelp("ctrl+z", "undo"),
+		),
+		redo: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "redo"),
+		),
+		wrap: key.NewBinding(
+			key.WithKeys("alt+z"),
+			key.WithHelp("alt+z", "wrap/no wrap"),
+		),
+		split: key.NewBinding(
+			key.WithKeys("alt+p"),
+			key.WithHelp("alt+p", "split preview"),
+		),
+		date: key.NewBinding(
+			key.WithKeys("alt+t"),
+			key.WithHelp("alt+t", "insert date"),
+		),
+		dateTime: key.NewBinding(
+			key.WithKeys("alt+T"),
+			key.WithHelp("alt+T", "insert date & time"),
+		),
+		dupLine: key.NewBinding(
+			key.WithKeys("alt+D"),
+			key.WithHelp("alt+D", "duplicate line"),
+		),
+		lineUp: key.NewBinding(
+			key.WithKeys("alt+up"),
+			key.WithHelp("alt+↑", "move line up"),
+		),
+		lineDown: key.NewBinding(
+			key.WithKeys("alt+down"),
+			key.WithHelp("alt+↓", "move line down"),
+		),
+		nextTask: key.NewBinding(
+			key.WithKeys("alt+n"),
+			key.WithHelp("alt+n", "next open task"),
+		),
+		prevTask: key.NewBinding(
+			key.WithKeys("alt+N"),
+			key.WithHelp("alt+N", "previous open task"),
+		),
+		indent: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "indent"),
+		),
+		dedent: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithHelp("shift+tab", "dedent line"),
+		),
+		reflow: key.NewBinding(
+			key.WithKeys("ctrl+j"),
+			key.WithHelp("ctrl+j", "reflow paragraph"),
+		),
+		table: key.NewBinding(
+			key.WithKeys("alt+|"),
+			key.WithHelp("alt+|", "format table"),
+		),
+		zen: key.NewBinding(
+			key.WithKeys("alt+f"),
+			key.WithHelp("alt+f", "zen mode"),
+		),
+		rename: key.NewBinding(
+			key.WithKeys("f2"),
+			key.WithHelp("f2", "rename note"),
+		),
+		diff: key.NewBinding(
+			key.WithKeys("alt+d"),
+			key.WithHelp("alt+d", "changes since save"),
+		),
+		readOnly: key.NewBinding(
+			key.WithKeys("alt+r"),
+			key.WithHelp("alt+r", "read-only"),
+		),
+		reload: key.NewBinding(
+			key.WithKeys("ctrl+l"),
+			key.WithHelp("ctrl+l", "reload from disk"),
+		),
+		cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		saveExit: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "save & exit"),
+		),
+		save: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save"),
+		),
+	}
+}
+
+type previewKeyMap struct {
+	scroll    key.Binding
+	side      key.Binding
+	topBottom key.Binding
+	halfPage  key.Binding
+	theme     key.Binding
+	lineNums  key.Binding
+	raw       key.Binding
+	wrap      key.Binding
+	toc       key.Binding
+	tocPrev   key.Binding
+	tocNext   key.Binding
+	lint      key.Binding
+	openLink  key.Binding
+	export    key.Binding
+	copy      key.Binding
+	copyText  key.Binding
+	back      key.Binding
+}
+
+func newPreviewKeyMap() *previewKeyMap {
+	return &previewKeyMap{
+		scroll: key.NewBinding(
+			key.WithKeys("up", "down"),
+			key.WithHelp("↑/↓", "scroll"),
+		),
+		side: key.NewBinding(
+			key.WithKeys("left", "right"),
+			key.WithHelp("←/→", "scroll sideways"),
+		),
+		topBottom: key.NewBinding(
+			key.WithKeys("g", "G"),
+			key.WithHelp("g/G", "top/bottom"),
+		),
+		halfPage: key.NewBinding(
+			key.WithKeys("ctrl+u", "ctrl+d"),
+			key.WithHelp("ctrl+u/d", "half page"),
+		),
+		theme: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "cycle theme"),
+		),
+		lineNums: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "source line numbers"),
+		),
+		raw: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "raw/rendered"),
+		),
+		wrap: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "wrap/no wrap"),
+		),
+		toc: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "contents"),
+		),
+		tocPrev: key.NewBinding(
+			key.WithKeys("["),
+			key.WithHelp("[", "prev heading"),
+		),
+		tocNext: key.NewBinding(
+			key.WithKeys("]"),
+			key.WithHelp("]", "next heading"),
+		),
+		lint: key.NewBinding(
+			key.WithKeys("!"),
+			key.WithHelp("!", "warnings"),
+		),
+		openLink: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "open link"),
+		),
+		export: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "export html"),
+		),
+		copy: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "copy markdown"),
+		),
+		copyText: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy as text"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc", "q", "ctrl+p"),
+			key.WithHelp("ctrl+p/q/esc", "back to editor"),
+		),
+	}
+}
+
+type globalKeyMap struct {
+	help    key.Binding
+	palette key.Binding
+	quit    key.Binding
+}
+
+func newGlobalKeyMap() *globalKeyMap {
+	return &globalKeyMap{
+		help: key.NewBinding(
+			key.WithKeys("?", "f1"),
+			key.WithHelp("?/f1", "toggle help"),
+		),
+		palette: key.NewBinding(
+			key.WithKeys("ctrl+k"),
+			key.WithHelp("ctrl+k", "command palette"),
+		),
+		quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
 	}
 }
 
 type model struct {
 	mainList     list.Model
 	todoList     list.Model
+	trashList    list.Model
+	paletteList  list.Model
+	recentList   list.Model
+	todayList    list.Model
+	templateList list.Model
+	archiveList  list.Model
 	textInput    textinput.Model
+	findInput    textinput.Model
+	replaceInput textinput.Model
+	lineInput    textinput.Model
+	searchInput  textinput.Model
 	editor       textarea.Model
 	viewport     viewport.Model
 	state        viewState
 	currentFile  string
+	currentExt   string
+	todoDir      string
+	previewTheme string
+	codeStyle    string
+	sortMode     sortMode
+	tagFilter    string
 	width        int
 	height       int
 	ready        bool
 	delegateKeys *delegateKeyMap
 	todoListKeys *todoListKeyMap
+	trashKeys    *trashKeyMap
+	archiveKeys  *archiveKeyMap
+	editorKeys   *editorKeyMap
+	previewKeys  *previewKeyMap
+	globalKeys   *globalKeyMap
+
+	// searchMatches are the occurrences of the editor search, the cursor
+	// being on searchIndex; searchOrigin is where the search started
+	searchMatches []searchMatch
+	searchIndex   int
+	searchOrigin  searchMatch
+
+	// previewLineNumbers prefixes the note's lines with their numbers when
+	// the preview shows it verbatim
+	previewLineNumbers bool
+
+	// rawPreview shows the file verbatim instead of rendering markdown
+	rawPreview bool
+
+	// previewNoWrap renders long lines unwrapped, to be scrolled sideways
+	previewNoWrap bool
+
+	// previewWidest is the width of the preview's longest line, to tell
+	// when there's more of it off either side
+	previewWidest int
+
+	// editorNoWrap scrolls long editor lines sideways instead of wrapping
+	editorNoWrap bool
+
+	// splitView shows a live preview beside the editor. splitSeen is the
+	// text the last refresh was scheduled for and splitRefreshID picks
+	// out that refresh from earlier ones
+	splitView      bool
+	splitSeen      string
+	splitRefreshID int
+
+	// previewStatus is a one-off message shown under the preview
+	previewStatus string
+
+	// toc lists the previewed note's headings, shown beside the preview
+	// while tocVisible; tocSelected is the heading [ and ] move from
+	toc         []tocEntry
+	tocVisible  bool
+	tocSelected int
+
+	// lint holds the markdown warnings for the previewed note, listed
+	// under the preview while lintVisible
+	lint        []lintWarning
+	lintVisible bool
+
+	// linkIndex is the next link in view to open, counted from the top of
+	// the preview when it was at linkOffset
+	linkIndex  int
+	linkOffset int
+
+	// createStatus explains why a name in createTodoView was rejected
+	createStatus string
+
+	// createExisting is the saved note a new name collided with
+	createExisting string
+
+	// diskModTime is when the note in the editor was last written, as of
+	// opening or saving it, so saves can tell if something else wrote it
+	// since. conflictClose closes the editor once confirmOverwriteView
+	// is answered.
+	diskModTime   time.Time
+	conflictClose bool
+
+	// peekVisible shows the head of the highlighted note beside the todo
+	// list. peekCache holds what's been read, and peekOffset is how far
+	// peekFile, the note last scrolled, is scrolled
+	peekVisible bool
+	peekCache   map[string]peekEntry
+	peekFile    string
+	peekOffset  int
+
+	// statusLifetimes is how long each kind of list status message lasts
+	statusLifetimes statusLifetimes
+
+	// jumpDigits is the number being typed in the todo list to jump to
+	jumpDigits string
+
+	// todoFolder is the folder the todo list is showing, relative to the
+	// todo directory, or "" for the top level
+	todoFolder string
+
+	// todoTotal is how many notes the todo directory held when the todo
+	// list was last loaded, shown in its title
+	todoTotal int
+
+	// loadErr is why the todo directory couldn't be read when the todo
+	// list or today's tasks were last loaded
+	loadErr error
+
+	// todosLoading is set while notes are read for the todo list by load
+	// todosLoadID, which is to select pendingSelect once they arrive.
+	// todayLoading and statsLoading do the same for their views
+	todosLoading  bool
+	todosLoadID   int
+	pendingSelect string
+	todayLoading  bool
+	statsLoading  bool
+
+	// startupCmd starts whatever the restored session needs loaded
+	startupCmd tea.Cmd
+
+	// exportSubfolders includes notes in subfolders in Export All
+	exportSubfolders bool
+
+	// exportCancel stops the Export All that exportUpdates reports on,
+	// and is nil once it's been asked to. exportDone of exportTotal notes
+	// are written so far, as drawn by exportProgress
+	exportCancel   context.CancelFunc
+	exportUpdates  chan tea.Msg
+	exportDone     int
+	exportTotal    int
+	exportProgress progress.Model
+
+	// returnTo is where createTodoView and the editor go back to once a
+	// new note is cancelled or closed
+	returnTo viewState
+
+	// prevState is the view to return to when the help overlay closes
+	prevState viewState
+
+	// quitReturn is the view to go back to when quitting is called off
+	quitReturn viewState
+
+	// retagFrom is the tag being renamed, once it's been entered, and
+	// retagStatus explains why the last tag typed was refused
+	retagFrom   string
+	retagStatus string
+
+	// lastSelected is the note highlighted when the todo list opens
+	lastSelected string
+
+	// lastErr is the most recent save error, shown in the editor footer
+	lastErr error
+
+	// dirty reports whether the editor has changes that aren't on disk yet
+	dirty bool
+
+	// counts is refreshed whenever the editor text changes
+	counts textCounts
+
+	// taskProgress draws counts' checklist progress in the editor footer
+	taskProgress progress.Model
+
+	// editorStatus is a one-off message shown in the editor footer
+	editorStatus string
+
+	// meta is the frontmatter of the file in the editor
+	meta noteMeta
+
+	// pinned holds the filenames kept at the top of the todo list
+	pinned map[string]bool
+
+	// labels maps filenames to their color label, and labelFilter limits
+	// the todo list to one label
+	labels      map[string]string
+	labelFilter string
+
+	// completion limits the todo list to notes with or without open tasks
+	completion completionFilter
+
+	// previewOffsets remembers how far each file was scrolled in preview
+	previewOffsets map[string]int
+
+	// recent lists recently opened files, most recent first
+	recent []string
+
+	// workspaces are the todo directories workspaceList switches between,
+	// the default one first, and workspace names the one todoDir is
+	workspaces    []workspace
+	workspace     string
+	workspaceList list.Model
+
+	// watcher reports changes made to the todo directory by others
+	watcher *todoWatcher
+
+	// marked holds the todos selected for a bulk action, and bulkAction
+	// what confirmBulkView is about to do with them
+	marked     map[string]bool
+	bulkAction bulkAction
+
+	// lastDeleted can be restored with u until its undo window expires
+	lastDeleted *deletedTodo
+
+	// index caches what notes say about themselves for listing them, when
+	// $GOTODO_INDEX turns it on
+	index *noteIndex
+
+	// cipher encrypts notes when a passphrase is set, and encrypted
+	// reports whether the note in the editor is stored encrypted
+	cipher    *noteCipher
+	encrypted bool
+
+	// gitAutoCommit commits saves, deletes and renames when the todo
+	// directory is a git repository
+	gitAutoCommit bool
+
+	// dateFormat and dateTimeFormat are the layouts the date keys insert
+	dateFormat     string
+	dateTimeFormat string
+
+	// namePattern is the time layout new note names are pre-filled from
+	namePattern string
+
+	// readOnly keeps the note in the editor from being changed, leaving
+	// only moving around it
+	readOnly bool
+
+	// emoji expands :shortcodes: as a space is typed after them
+	emoji bool
+
+	// reflowWidth is the column the reflow key wraps paragraphs at
+	reflowWidth int
+
+	// tabWidth is how many spaces tab indents by
+	tabWidth int
+
+	// cursors is where the cursor was left in each note, by filename
+	cursors map[string]cursorPos
+
+	// menuUses counts how often each main menu entry has been chosen,
+	// by title
+	menuUses map[string]int
+
+	// diffViewport shows the buffer's changes since the last save, with
+	// diffSummary counting them for its header
+	diffViewport viewport.Model
+	diffSummary  string
+
+	// zen hides the title, header, help and borders around the editor
+	zen bool
+
+	// editedWindow is how recently a note must have changed for the todo
+	// list to badge it
+	editedWindow time.Duration
+
+	// openAtEnd opens notes with the cursor after their last line
+	// rather than at the top
+	openAtEnd bool
+
+	// autosaveID identifies the live autosave timer; ticks from older
+	// timers are ignored, which is how leaving the editor stops it
+	autosaveID int
+	autosaved  bool
+
+	// undoStack and redoStack hold editor snapshots; lastEditAt groups
+	// bursts of typing into a single undo step
+	undoStack  []editorSnapshot
+	redoStack  []editorSnapshot
+	lastEditAt time.Time
+
+	// stats is computed each time the statistics view is opened
+	stats todoStats
+
+	// renameTarget is the todo being renamed in renameTodoView
+	renameTarget todoItem
 }
 
 func newTextarea() textarea.Model {
@@ -162,220 +1004,1474 @@ func newTextarea() textarea.Model {
 	t.BlurredStyle.Base = blurredBorderStyle
 	t.FocusedStyle.EndOfBuffer = endOfBufferStyle
 	t.BlurredStyle.EndOfBuffer = endOfBufferStyle
-	t.KeyMap.DeleteWordBackward.SetEnabled(false)
+	t.KeyMap.DeleteWordBackward.SetHelp("ctrl+w", "delete word")
+	// alt+d shows the diff, so deleting the next word is alt+delete only
+	t.KeyMap.DeleteWordForward.SetKeys("alt+delete")
 	t.Focus()
 	return t
 }
 
-func (m *model) loadTodoFiles() []list.Item {
+// resolveTodoDir returns the directory notes are stored in. It honours
+// $GOTODO_DIR (expanding a leading ~ and relative paths) and falls back
+// to ~/todo when unset.
+func resolveTodoDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return []list.Item{}
+		return "", err
+	}
+
+	dir := os.Getenv("GOTODO_DIR")
+	if dir == "" {
+		return filepath.Join(homeDir, "todo"), nil
+	}
+
+	return filepath.Abs(expandHome(dir, homeDir))
+}
+
+// autosaveInterval is how often the editor writes a dirty buffer to disk.
+const autosaveInterval = 30 * time.Second
+
+type autosaveTickMsg struct {
+	id int
+}
+
+// startAutosave starts a fresh autosave timer, invalidating any previous one.
+func (m *model) startAutosave() tea.Cmd {
+	m.autosaveID++
+	id := m.autosaveID
+	return tea.Tick(autosaveInterval, func(time.Time) tea.Msg {
+		return autosaveTickMsg{id: id}
+	})
+}
+
+// quit exits the program, first asking for confirmation when the editor
+// holds unsaved changes.
+func (m *model) quit() tea.Cmd {
+	if !m.dirty {
+		return tea.Quit
 	}
+	m.quitReturn = m.state
+	m.editor.Blur()
+	m.state = confirmQuitView
+	return nil
+}
 
-	todoDir := filepath.Join(homeDir, "todo")
+// closeEditor clears the editor buffer and its state and returns to the
+// main list, or to the reloaded todo list with the note selected when it
+// was created from there.
+func (m *model) closeEditor() tea.Cmd {
+	filename := m.currentFileName()
+	m.rememberCursor()
+	m.loadEditor("")
+	m.lastErr = nil
+	m.editorStatus = ""
+	m.autosaved = false
+	m.state = m.returnTo
+	m.returnTo = listView
+	if m.state != todoListView {
+		return nil
+	}
+	m.todoFolder = noteFolder(filename)
+	m.sizeTodoList()
+	cmd := m.reloadTodoList()
+	m.selectTodo(filename)
+	return cmd
+}
 
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(todoDir, 0755); err != nil {
-		return []list.Item{}
+// openTodoList switches to a fresh todo list and starts loading the
+// todo files into it, highlighting the last selected note if present.
+func (m *model) openTodoList() tea.Cmd {
+	m.todoList = list.New(nil, newTodoDelegate(), 0, 0)
+	m.jumpDigits = ""
+	m.todoTotal = 0
+	m.todoList.Title = m.todoListTitle()
+	m.todoList.Styles.Title = todoTitleStyle
+	keys := m.todoListKeys
+	m.todoList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.rename, keys.duplicate, keys.export, keys.tag, keys.sort, keys.pin}
 	}
 
-	files, err := os.ReadDir(todoDir)
-	if err != nil {
-		return []list.Item{}
+	m.sizeTodoList()
+
+	m.pendingSelect = m.lastSelected
+	m.todoFolder = noteFolder(m.lastSelected)
+	m.state = todoListView
+	return m.reloadTodoList()
+}
+
+// loadErrView is the line shown under a list of notes when the todo
+// directory couldn't be read, or "" when it could.
+func (m model) loadErrView() string {
+	if m.loadErr == nil {
+		return ""
 	}
+	return errorMessageStyle.Render("Can't read todos: " + m.loadErr.Error())
+}
 
-	var items []list.Item
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-			filePath := filepath.Join(todoDir, file.Name())
-			fileInfo, err := os.Stat(filePath)
-			modTimeStr := ""
-			if err == nil {
-				modTimeStr = "Modified: " + fileInfo.ModTime().Format("Jan 02, 2006 3:04 PM")
-			}
+// loadErrHeight is the number of rows loadErrView takes.
+func (m model) loadErrHeight() int {
+	if m.loadErr == nil {
+		return 0
+	}
+	return lipgloss.Height(m.loadErrView())
+}
 
-			items = append(items, todoItem{
-				filename: file.Name(),
-				modTime:  modTimeStr,
-			})
+// withLoadErr adds loadErrView under a rendered list.
+func (m model) withLoadErr(view string) string {
+	if m.loadErr == nil {
+		return view
+	}
+	return view + "\n" + m.loadErrView()
+}
+
+// todoListTitle names the todo list, with how many of the notes are
+// showing and whatever is narrowing them down, e.g.
+// "All Todos (12/40 · #work · red)".
+func (m model) todoListTitle() string {
+	var parts []string
+	if m.todoTotal > 0 {
+		visible := 0
+		for _, it := range m.todoList.VisibleItems() {
+			if _, ok := it.(todoItem); ok {
+				visible++
+			}
 		}
+		parts = append(parts, fmt.Sprintf("%d/%d", visible, m.todoTotal))
+	}
+	if m.tagFilter != "" {
+		parts = append(parts, "#"+m.tagFilter)
+	}
+	if m.labelFilter != "" {
+		parts = append(parts, m.labelFilter)
+	}
+	if m.completion != showAllNotes {
+		parts = append(parts, m.completion.String())
+	}
+	if filter := m.todoList.FilterValue(); filter != "" {
+		parts = append(parts, "“"+filter+"”")
 	}
+	if len(parts) == 0 {
+		return "All Todos"
+	}
+	return "All Todos (" + strings.Join(parts, " · ") + ")"
+}
 
-	return items
+// selectTodo moves the todo list selection to filename, if it's listed.
+// filename can also be the path of a folder.
+func (m *model) selectTodo(filename string) {
+	if m.todosLoading {
+		m.pendingSelect = filename
+	}
+	for i, it := range m.todoList.Items() {
+		todo, isTodo := it.(todoItem)
+		folder, isFolder := it.(folderItem)
+		if (isTodo && todo.filename == filename) || (isFolder && folder.path == filename) {
+			m.todoList.Select(i)
+			return
+		}
+	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return tea.Batch(m.watcher.wait(), m.startupCmd)
 }
 
+// Update handles msg, then catches the todo list's side panel up with the
+// selection and schedules a refresh of the split preview if msg changed
+// the note, whichever way it was changed.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := m.update(msg)
+	nm, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+	if nm.state != m.state {
+		log.Printf("view %s -> %s", m.state, nm.state)
+	}
+	if nm.state == todoListView && nm.peekActive() {
+		nm.loadPeek()
+	}
+	if nm.state == editorView {
+		// Lines may have grown past the unwrapped textarea
+		nm.fitNoWrapWidth()
+	}
+	if !nm.splitActive() || nm.editor.Value() == nm.splitSeen {
+		return nm, cmd
+	}
+	nm.splitSeen = nm.editor.Value()
+	return nm, tea.Batch(cmd, nm.scheduleSplitRefresh())
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" {
-			return m, tea.Quit
+		if key.Matches(msg, m.globalKeys.quit) {
+			// A second ctrl+c at the prompt quits regardless
+			if m.state == confirmQuitView {
+				return m, tea.Quit
+			}
+			return m, m.quit()
+		}
+
+		// Toggle the help overlay. Printable keys like "?" are left alone
+		// in views that take text input, where f1 still works.
+		if m.state == helpView {
+			if key.Matches(msg, m.globalKeys.help) || msg.String() == "esc" {
+				m.state = m.prevState
+				if m.state == editorView {
+					m.editor.Focus()
+					return m, tea.Batch(textarea.Blink, m.startAutosave())
+				}
+			}
+			return m, nil
+		}
+		if key.Matches(msg, m.globalKeys.help) && (msg.Type != tea.KeyRunes || !m.takesTextInput()) {
+			m.prevState = m.state
+			m.state = helpView
+			return m, nil
+		}
+
+		// The palette is offered in the editor too, taking ctrl+k over
+		// from the textarea's delete-to-end-of-line
+		if key.Matches(msg, m.globalKeys.palette) && m.state != paletteView &&
+			(m.state == editorView || !m.takesTextInput()) {
+			m.openPalette()
+			return m, nil
 		}
 
 		// Handle different views
 		switch m.state {
 		case listView:
-			if msg.String() == "enter" {
+			if key.Matches(msg, m.mainList.KeyMap.Quit) && m.mainList.FilterState() == list.Unfiltered {
+				return m, m.quit()
+			}
+			if key.Matches(msg, m.delegateKeys.recent) && m.mainList.FilterState() != list.Filtering {
+				m.openRecent()
+				return m, nil
+			}
+			if key.Matches(msg, m.delegateKeys.scratch) && m.mainList.FilterState() != list.Filtering {
+				// Open the scratchpad without going through naming a note
+				return m, m.openScratch()
+			}
+			if key.Matches(msg, m.delegateKeys.choose) {
 				// Get selected item
 				selected := m.mainList.SelectedItem()
 				if selected != nil {
 					selectedItem := selected.(item)
+					m.countMenuUse(selectedItem.title)
 					if selectedItem.title == "Create Todo" {
 						// Switch to create todo view
-						m.state = createTodoView
+						return m, m.openCreate(listView, "")
+					} else if selectedItem.title == "List All Todos" {
+						// Switch to the todo list and load todos into it
+						return m, m.openTodoList()
+					} else if selectedItem.title == "Today" {
+						// Gather today's tasks from every note
+						return m, m.openToday()
+					} else if selectedItem.title == "Statistics" {
+						// Switch to the dashboard and recompute stats for it
+						m.statsLoading = true
+						m.state = statsView
+						return m, m.loadStats()
+					} else if selectedItem.title == "Export All" {
+						// Ask where to write the combined file
+						m.textInput.SetValue(exportAllName(time.Now()))
+						m.textInput.CursorEnd()
 						m.textInput.Focus()
+						m.state = exportAllView
 						return m, textinput.Blink
-					} else if selectedItem.title == "List All Todos" {
-						// Load todos and switch to todo list view
-						items := m.loadTodoFiles()
-						delegate := list.NewDefaultDelegate()
-						m.todoList = list.New(items, delegate, 0, 0)
-						m.todoList.Title = "All Todos"
-						m.todoList.Styles.Title = todoTitleStyle
+					} else if selectedItem.title == "Archive" {
+						// Load archived todos and switch to the archive view
+						m.openArchive()
+						return m, nil
+					} else if selectedItem.title == "Trash" {
+						// Load deleted todos and switch to trash view
+						m.openTrash()
+						return m, nil
+					} else if selectedItem.title == "Workspaces" {
+						// Pick another todo directory to work in
+						m.openWorkspaces()
+						return m, nil
+					} else if selectedItem.title == "Rename Tag" {
+						// Ask for the tag and its new name
+						return m, m.openRenameTag()
+					}
+				}
+			}
+		case createTodoView:
+			switch msg.String() {
+			case "enter":
+				// Save the filename and switch to editor
+				if m.textInput.Value() != "" {
+					// Default the extension unless the user typed one,
+					// and keep the note inside the todo directory
+					fileName, err := todoFileName(m.textInput.Value(), noteExtensions[0])
+					if err != nil {
+						m.createStatus = err.Error()
+						return m, nil
+					}
 
-						h, v := docStyle.GetFrameSize()
-						m.todoList.SetSize(m.width-h, m.height-v)
+					// Don't let a new note clobber one that's already saved
+					if existing, ok := m.existingNote(fileName); ok {
+						m.createExisting = existing
+						m.createStatus = ""
+						m.state = confirmExistingView
+						return m, nil
+					}
+
+					m.currentFile = noteName(fileName)
+					m.currentExt = baseExt(fileName)
+					m.createStatus = ""
+					m.textInput.SetValue("")
 
-						m.state = todoListView
+					// Offer a starting point when there are templates
+					if templates := m.loadTemplates(); len(templates) > 0 {
+						m.openTemplatePicker(templates)
 						return m, nil
 					}
+					return m, m.newTodo("")
+				}
+				return m, nil
+			case "esc":
+				// Cancel and return to where the note was started from
+				m.textInput.SetValue("")
+				m.createStatus = ""
+				m.state = m.returnTo
+				m.returnTo = listView
+				return m, nil
+			}
+		case editorView:
+			switch {
+			case key.Matches(msg, m.editorKeys.cancel):
+				// Ask before throwing away unsaved changes
+				if m.dirty {
+					m.state = confirmDiscardView
+					return m, nil
+				}
+
+				// Return to list, nothing to lose
+				return m, m.closeEditor()
+			case key.Matches(msg, m.editorKeys.reload):
+				// Pick up changes made elsewhere, asking before dropping ours
+				if m.dirty {
+					m.editor.Blur()
+					m.state = confirmReloadView
+					return m, nil
+				}
+				m.reloadNote()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.readOnly):
+				// Lock or unlock the note against changes
+				m.toggleReadOnly()
+				return m, nil
+			case m.readOnly && m.editsBuffer(msg):
+				// Nothing changes the note while it's read-only
+				m.editorStatus = "Read-only, " + m.editorKeys.readOnly.Help().Key + " to edit"
+				return m, nil
+			case key.Matches(msg, m.editorKeys.save):
+				// Save file and continue editing
+				if m.lastErr = m.saveFile(); errors.Is(m.lastErr, errChangedOnDisk) {
+					m.lastErr = nil
+					m.openSaveConflict(false)
+					return m, nil
+				}
+				if m.lastErr != nil {
+					return m, nil
+				}
+				return m, m.commitCurrentFile()
+			case key.Matches(msg, m.editorKeys.saveExit):
+				// Save file and return to list, staying put if it failed
+				if m.lastErr = m.saveFile(); errors.Is(m.lastErr, errChangedOnDisk) {
+					m.lastErr = nil
+					m.openSaveConflict(true)
+					return m, nil
+				}
+				if m.lastErr != nil {
+					return m, nil
+				}
+				cmd := m.commitCurrentFile()
+				return m, tea.Batch(cmd, m.closeEditor())
+			case key.Matches(msg, m.editorKeys.toggleTask):
+				// Toggle the checkbox on the current line
+				m.toggleCurrentCheckbox()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.replace):
+				// Open the find and replace prompt
+				m.editor.Blur()
+				m.findInput.Focus()
+				m.replaceInput.Blur()
+				m.state = replaceView
+				return m, textinput.Blink
+			case key.Matches(msg, m.editorKeys.undo):
+				m.undo()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.redo):
+				m.redo()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.date):
+				m.insertText(time.Now().Format(m.dateFormat))
+				return m, nil
+			case key.Matches(msg, m.editorKeys.dateTime):
+				m.insertText(time.Now().Format(m.dateTimeFormat))
+				return m, nil
+			case key.Matches(msg, m.editorKeys.dupLine):
+				m.duplicateLine()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.lineUp):
+				// Reorder lines, e.g. checklist items, without cut and paste
+				m.moveLine(-1)
+				return m, nil
+			case key.Matches(msg, m.editorKeys.lineDown):
+				m.moveLine(1)
+				return m, nil
+			case key.Matches(msg, m.editorKeys.nextTask):
+				// Hop between unchecked boxes, wrapping at either end
+				m.jumpToOpenTask(1)
+				return m, nil
+			case key.Matches(msg, m.editorKeys.prevTask):
+				m.jumpToOpenTask(-1)
+				return m, nil
+			case key.Matches(msg, m.editorKeys.diff):
+				// Compare the buffer with what's saved
+				m.openDiff()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.rename):
+				// Edit the name in the header
+				return m, m.openRenameNote()
+			case key.Matches(msg, m.editorKeys.zen):
+				// Hide everything but the text, or bring it all back
+				m.toggleZen()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.reflow):
+				// Rewrap the paragraph around the cursor
+				m.reflowParagraph()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.table):
+				// Line up the columns of the table around the cursor
+				m.formatTableAtCursor()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.indent):
+				// The textarea ignores tab, so indent by the configured width
+				m.indent()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.dedent):
+				m.dedent()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.wrap):
+				m.editorNoWrap = !m.editorNoWrap
+				m.sizeEditor()
+				return m, nil
+			case key.Matches(msg, m.editorKeys.split):
+				// Show a live preview beside the editor, when there's room
+				m.splitView = !m.splitView
+				m.sizeEditor()
+				if m.splitActive() {
+					m.initSplitPreview()
+				} else if m.splitView {
+					m.editorStatus = "Too narrow to split, widen the window or use ctrl+p"
+				}
+				return m, nil
+			case key.Matches(msg, m.editorKeys.external):
+				// Hand the file to $EDITOR, saving first so it sees our changes
+				return m, m.openExternalEditor()
+			case key.Matches(msg, m.editorKeys.search):
+				// Search the buffer as the query is typed, taking ctrl+f
+				// over from the textarea's cursor-forward
+				return m, m.openSearch()
+			case key.Matches(msg, m.editorKeys.gotoLine):
+				// Prompt for a line to jump to
+				m.editor.Blur()
+				m.lineInput.Focus()
+				m.state = gotoLineView
+				return m, textinput.Blink
+			case key.Matches(msg, m.editorKeys.preview):
+				// Switch to preview
+				m.openPreview()
+				return m, nil
+			case m.emoji && !m.readOnly && msg.Type == tea.KeySpace:
+				// Turn a :shortcode: into its emoji, then type the space
+				m.expandShortcode()
+			}
+		case previewView:
+			if key.Matches(msg, m.previewKeys.theme) {
+				// Switch to the next glamour theme and re-render
+				m.previewTheme = nextPreviewTheme(m.previewTheme)
+				m.refreshPreview()
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.raw) {
+				// Switch between rendered markdown and the raw source
+				m.rawPreview = !m.rawPreview
+				m.refreshPreview()
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.wrap) {
+				// Switch between wrapping to the viewport and long lines
+				m.previewNoWrap = !m.previewNoWrap
+				m.refreshPreview()
+				m.viewport.SetXOffset(0)
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.export) {
+				// Export the previewed content to HTML
+				htmlName, err := m.exportHTML(m.currentFileName(), m.editor.Value())
+				if err != nil {
+					m.previewStatus = "Export failed: " + err.Error()
+				} else {
+					m.previewStatus = "Exported " + htmlName
+				}
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.copy, m.previewKeys.copyText) {
+				// Copy the note to the system clipboard
+				rendered := key.Matches(msg, m.previewKeys.copyText)
+				m.previewStatus = copyNote(m.currentFileName(), m.editor.Value(), rendered)
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.toc) {
+				// Show or hide the table of contents, re-wrapping to the
+				// width it leaves
+				m.tocVisible = !m.tocVisible
+				m.viewport.Width = m.previewWidth()
+				m.refreshPreview()
+				m.selectHeadingAt(m.viewport.YOffset)
+				return m, nil
+			}
+			if m.tocVisible && key.Matches(msg, m.previewKeys.tocPrev) {
+				m.jumpToHeading(m.tocSelected - 1)
+				return m, nil
+			}
+			if m.tocVisible && key.Matches(msg, m.previewKeys.tocNext) {
+				m.jumpToHeading(m.tocSelected + 1)
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.lint) {
+				// Show or hide the warnings panel, giving its rows back to
+				// the preview when it's hidden
+				m.lintVisible = !m.lintVisible
+				m.viewport.Height = m.height - m.previewVerticalMargin()
+				return m, nil
+			}
+			if key.Matches(msg, m.previewKeys.openLink) {
+				// Open a link in view, the next one on each press
+				return m, m.openNextLink()
+			}
+			if key.Matches(msg, m.previewKeys.lineNums) {
+				// Toggle line numbers and re-render. Rendered markdown no
+				// longer lines up with the source, so they're only drawn
+				// alongside it, where they match the editor's go-to-line
+				m.previewLineNumbers = !m.previewLineNumbers
+				if m.previewLineNumbers && m.renderedPreview() {
+					m.previewStatus = "Line numbers show in raw mode (" + m.previewKeys.raw.Help().Key + ")"
+				}
+				m.refreshPreview()
+				return m, nil
+			}
+
+			switch {
+			case key.Matches(msg, m.previewKeys.back):
+				// Return to editor
+				m.rememberPreviewOffset()
+				m.state = editorView
+				m.editor.Focus()
+				if m.splitActive() {
+					m.initSplitPreview()
+				}
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			}
+		case templateView:
+			if m.templateList.FilterState() == list.Filtering {
+				break
+			}
+			switch msg.String() {
+			case "esc":
+				// Back to naming the note
+				m.textInput.SetValue(m.currentFile)
+				m.textInput.CursorEnd()
+				m.state = createTodoView
+				return m, textinput.Blink
+			case "enter":
+				if selected, ok := m.templateList.SelectedItem().(item); ok {
+					return m, m.applyTemplate(selected.title)
+				}
+				return m, nil
+			}
+		case todayView:
+			if m.todayList.FilterState() == list.Filtering {
+				break
+			}
+			switch msg.String() {
+			case "esc":
+				m.state = listView
+				return m, nil
+			case "enter":
+				if selected, ok := m.todayList.SelectedItem().(todayTask); ok {
+					return m, m.openTodayTask(selected)
+				}
+				return m, nil
+			}
+		case recentView:
+			if m.recentList.FilterState() == list.Filtering {
+				break
+			}
+			switch msg.String() {
+			case "esc":
+				m.state = listView
+				return m, nil
+			case "enter":
+				if selected, ok := m.recentList.SelectedItem().(item); ok {
+					return m, m.openRecentItem(selected.title)
+				}
+				return m, nil
+			}
+		case workspaceView:
+			if m.workspaceList.FilterState() == list.Filtering {
+				break
+			}
+			switch msg.String() {
+			case "esc":
+				m.state = listView
+				return m, nil
+			case "enter":
+				if selected, ok := m.workspaceList.SelectedItem().(item); ok {
+					return m, m.switchWorkspace(findWorkspace(m.workspaces, selected.title))
+				}
+				return m, nil
+			}
+		case paletteView:
+			switch msg.String() {
+			case "esc":
+				return m, m.closePalette()
+			case "up":
+				// The list leaves arrows to the filter input while it's focused
+				m.paletteList.CursorUp()
+				return m, nil
+			case "down":
+				m.paletteList.CursorDown()
+				return m, nil
+			case "enter":
+				if selected, ok := m.paletteList.SelectedItem().(paletteItem); ok {
+					return m.runPaletteItem(selected)
+				}
+				return m, nil
+			}
+		case todoListView:
+			// Let the list handle keys while the filter input is active
+			if m.todoList.FilterState() == list.Filtering {
+				break
+			}
+
+			if cmd, ok := m.handleJump(msg); ok {
+				return m, cmd
+			}
+
+			if folder, ok := m.todoList.SelectedItem().(folderItem); ok {
+				// Folders can only be opened; the keys for notes skip them
+				if key.Matches(msg, m.delegateKeys.choose, m.todoListKeys.preview) {
+					return m, m.enterFolder(folder.path)
+				}
+				if key.Matches(msg, m.todoListKeys.rename, m.todoListKeys.duplicate, m.todoListKeys.export,
+					m.todoListKeys.copy, m.todoListKeys.copyText, m.todoListKeys.mark, m.todoListKeys.archive,
+					m.todoListKeys.label, m.todoListKeys.pin, m.delegateKeys.remove) {
+					return m, nil
+				}
+			}
+
+			if key.Matches(msg, m.todoListKeys.parent) {
+				// Go up to the folder holding this one; there's nothing
+				// above the top level
+				if m.todoFolder == "" {
+					return m, nil
+				}
+				return m, m.leaveFolder()
+			}
+
+			if key.Matches(msg, m.todoListKeys.rename) {
+				// Prompt for a new name for the selected todo file
+				selected := m.todoList.SelectedItem()
+				if selected != nil {
+					m.renameTarget = selected.(todoItem)
+					m.textInput.SetValue(noteName(m.renameTarget.filename))
+					m.textInput.CursorEnd()
+					m.textInput.Focus()
+					m.state = renameTodoView
+					return m, textinput.Blink
+				}
+				return m, nil
+			}
+
+			if key.Matches(msg, m.todoListKeys.duplicate) {
+				// Copy the selected todo file and select the copy
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				newName, err := m.duplicateTodo(selected.(todoItem).filename)
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Duplicate failed: "+err.Error())
+				}
+
+				cmd := m.reloadTodoList()
+				m.selectTodo(newName)
+				statusCmd := m.listStatus(&m.todoList, statusNotice, "Created "+newName)
+				return m, tea.Batch(cmd, statusCmd)
+			}
+
+			if key.Matches(msg, m.todoListKeys.export) {
+				// Export the selected todo file to HTML next to it
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				filename := selected.(todoItem).filename
+				content, err := m.readNote(filename)
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Export failed: "+err.Error())
+				}
+				htmlName, err := m.exportHTML(filename, string(content))
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Export failed: "+err.Error())
+				}
+				return m, m.listStatus(&m.todoList, statusNotice, "Exported "+htmlName)
+			}
+
+			if key.Matches(msg, m.todoListKeys.copy, m.todoListKeys.copyText) {
+				// Copy the selected todo file to the system clipboard
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				filename := selected.(todoItem).filename
+				content, err := m.readNote(filename)
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Copy failed: "+err.Error())
+				}
+				status := copyNote(filename, string(content), key.Matches(msg, m.todoListKeys.copyText))
+				return m, m.listStatus(&m.todoList, statusInfo, status)
+			}
+
+			if key.Matches(msg, m.todoListKeys.undoDelete) && m.lastDeleted != nil {
+				// Bring back the note that was just deleted
+				filename, err := m.undoDelete()
+				if err != nil {
+					if errors.Is(err, os.ErrExist) {
+						return m, m.listStatus(&m.todoList, statusError, "Undo failed: a new "+filename+" exists")
+					}
+					return m, m.listStatus(&m.todoList, statusError, "Undo failed: "+err.Error())
+				}
+				cmd := m.reloadTodoList()
+				m.selectTodo(filename)
+				statusCmd := m.listStatus(&m.todoList, statusNotice, "Restored "+filename)
+				return m, tea.Batch(cmd, statusCmd, m.gitCommit("restore "+filename, filename))
+			}
+
+			if key.Matches(msg, m.todoListKeys.mark) {
+				// Mark or unmark the selected todo and move on to the next
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				todo := selected.(todoItem)
+				m.toggleMark(todo.filename)
+				todo.marked = m.marked[todo.filename]
+				cmd := m.todoList.SetItem(m.todoList.GlobalIndex(), todo)
+				m.todoList.CursorDown()
+				return m, cmd
+			}
+
+			if key.Matches(msg, m.todoListKeys.bulkTrash, m.todoListKeys.bulkArch) {
+				// Confirm before acting on everything marked
+				if len(m.marked) == 0 {
+					return m, m.listStatus(&m.todoList, statusInfo, "Nothing marked, use space to mark todos")
+				}
+				m.bulkAction = bulkTrash
+				if key.Matches(msg, m.todoListKeys.bulkArch) {
+					m.bulkAction = bulkArchive
+				}
+				m.state = confirmBulkView
+				return m, nil
+			}
+
+			if key.Matches(msg, m.todoListKeys.archive) {
+				// Move the selected todo file out of the list into the archive
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				filename := selected.(todoItem).filename
+				if err := m.archiveTodo(filename); err != nil {
+					if errors.Is(err, os.ErrExist) {
+						return m, m.listStatus(&m.todoList, statusInfo, filename+" is already archived")
+					}
+					return m, m.listStatus(&m.todoList, statusError, "Archive failed: "+err.Error())
+				}
+				delete(m.pinned, filename)
+				delete(m.marked, filename)
+
+				cmd := m.reloadTodoList()
+				statusCmd := m.listStatus(&m.todoList, statusNotice, "Archived "+filename)
+				commitCmd := m.gitCommit("archive "+filename, filename, path.Join(archiveDirName, filename))
+				return m, tea.Batch(cmd, statusCmd, commitCmd)
+			}
+
+			if key.Matches(msg, m.todoListKeys.newTodo) {
+				// Create a note without going back through the main menu,
+				// coming back here once it's done
+				return m, m.openCreate(todoListView, m.todoFolder)
+			}
+
+			if key.Matches(msg, m.todoListKeys.peek) {
+				// Show or hide the side panel, giving the list its width
+				m.peekVisible = !m.peekVisible
+				m.sizeTodoList()
+				return m, nil
+			}
+			if m.peekActive() && key.Matches(msg, m.todoListKeys.peekDown, m.todoListKeys.peekUp) {
+				delta := 1
+				if key.Matches(msg, m.todoListKeys.peekUp) {
+					delta = -1
+				}
+				m.scrollPeek(delta)
+				return m, nil
+			}
+
+			if key.Matches(msg, m.todoListKeys.tag) {
+				// Prompt for a tag to filter by
+				m.textInput.SetValue(m.tagFilter)
+				m.textInput.CursorEnd()
+				m.textInput.Focus()
+				m.state = tagFilterView
+				return m, textinput.Blink
+			}
+
+			if key.Matches(msg, m.todoListKeys.label) {
+				// Move the selected todo on to its next color label
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				selectedTodo := selected.(todoItem)
+				label := m.cycleLabel(selectedTodo.filename)
+
+				items := m.todoList.Items()
+				for i, it := range items {
+					if todo, ok := it.(todoItem); ok && todo.filename == selectedTodo.filename {
+						todo.label = label
+						items[i] = todo
+					}
+				}
+				cmd := m.todoList.SetItems(items)
+				status := "Removed the label from " + selectedTodo.filename
+				if label != "" {
+					status = "Labelled " + selectedTodo.filename + " " + label
+				}
+				return m, tea.Batch(cmd, m.listStatus(&m.todoList, statusInfo, status))
+			}
+
+			if key.Matches(msg, m.todoListKeys.byLabel) {
+				// Show only notes with the next label, then everything again
+				m.labelFilter = nextLabel(m.labelFilter)
+				m.todoList.Title = m.todoListTitle()
+				return m, m.reloadTodoList()
+			}
+
+			if key.Matches(msg, m.todoListKeys.byDone) {
+				// Show notes with open tasks, then complete ones, then all
+				m.completion = m.completion.next()
+				m.todoList.Title = m.todoListTitle()
+				return m, m.reloadTodoList()
+			}
+
+			if key.Matches(msg, m.todoListKeys.pin) {
+				// Pin or unpin the selected todo and re-sort around it
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				selectedTodo := selected.(todoItem)
+				status := m.togglePin(selectedTodo.filename)
+
+				items := m.todoList.Items()
+				for i, it := range items {
+					if todo, ok := it.(todoItem); ok && todo.filename == selectedTodo.filename {
+						todo.pinned = m.pinned[todo.filename]
+						items[i] = todo
+					}
+				}
+				sortTodoItems(items, m.sortMode)
+				cmd := m.todoList.SetItems(items)
+				m.selectTodo(selectedTodo.filename)
+				statusCmd := m.listStatus(&m.todoList, statusInfo, status+" "+selectedTodo.filename)
+				return m, tea.Batch(cmd, statusCmd)
+			}
+
+			if key.Matches(msg, m.todoListKeys.sort) {
+				// Cycle the sort mode and re-sort the current items
+				m.sortMode = m.sortMode.next()
+				items := m.todoList.Items()
+				sortTodoItems(items, m.sortMode)
+				cmd := m.todoList.SetItems(items)
+				statusCmd := m.listStatus(&m.todoList, statusInfo, "Sorted by "+m.sortMode.String())
+				return m, tea.Batch(cmd, statusCmd)
+			}
+
+			switch {
+			case key.Matches(msg, m.todoListKeys.back):
+				// Clear any marks first, then return to main list
+				if len(m.marked) > 0 {
+					m.marked = nil
+					return m, m.reloadTodoList()
+				}
+				m.state = listView
+				return m, nil
+			case key.Matches(msg, m.todoListKeys.preview):
+				// Open selected todo file in preview mode
+				selected := m.todoList.SelectedItem()
+				if selected != nil {
+					selectedTodo := selected.(todoItem)
+
+					// Load the file content
+					content, err := m.readNote(selectedTodo.filename)
+					if err != nil {
+						return m, m.listStatus(&m.todoList, statusError, "Open failed: "+err.Error())
+					}
+					m.currentFile = noteName(selectedTodo.filename)
+					m.currentExt = baseExt(selectedTodo.filename)
+					m.encrypted = selectedTodo.encrypted
+					m.loadEditor(string(content))
+					m.pushRecent(selectedTodo.filename)
+					m.openPreview()
+				}
+				return m, nil
+			case key.Matches(msg, m.delegateKeys.choose):
+				// Open selected todo file
+				selected := m.todoList.SelectedItem()
+				if selected != nil {
+					if cmd, err := m.openInEditor(selected.(todoItem).filename); err == nil {
+						return m, cmd
+					}
+				}
+				return m, nil
+			case key.Matches(msg, m.delegateKeys.remove):
+				// Move the selected todo file to the trash
+				selected := m.todoList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				selectedTodo := selected.(todoItem)
+				expireCmd, err := m.deleteTodo(selectedTodo.filename)
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Delete failed: "+err.Error())
+				}
+
+				// Reload the list, keeping the undo hint up as long as undo works
+				cmd := m.reloadTodoList()
+				lifetime := m.todoList.StatusMessageLifetime
+				m.todoList.StatusMessageLifetime = undoDeleteWindow
+				statusCmd := m.todoList.NewStatusMessage(statusMessageStyle("Deleted " + selectedTodo.filename + " — press u to undo"))
+				m.todoList.StatusMessageLifetime = lifetime
+				commitCmd := m.gitCommit("delete "+selectedTodo.filename, selectedTodo.filename)
+				return m, tea.Batch(cmd, statusCmd, expireCmd, commitCmd)
+			}
+		case trashView:
+			// Let the list handle keys while the filter input is active
+			if m.trashList.FilterState() == list.Filtering {
+				break
+			}
+
+			switch {
+			case key.Matches(msg, m.trashKeys.back):
+				// Return to main list
+				m.state = listView
+				return m, nil
+			case key.Matches(msg, m.trashKeys.restore):
+				// Move the selected note back to its original location
+				selected := m.trashList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				trashed := selected.(trashItem)
+				if err := m.restoreFromTrash(trashed); err != nil {
+					if errors.Is(err, os.ErrExist) {
+						return m, m.listStatus(&m.trashList, statusError, trashed.original+" already exists")
+					}
+					return m, m.listStatus(&m.trashList, statusError, "Restore failed: "+err.Error())
+				}
+				cmd := m.trashList.SetItems(m.loadTrash())
+				statusCmd := m.listStatus(&m.trashList, statusNotice, "Restored "+trashed.original)
+				return m, tea.Batch(cmd, statusCmd, m.gitCommit("restore "+trashed.original, trashed.original))
+			case key.Matches(msg, m.trashKeys.empty):
+				// Ask before permanently deleting everything
+				if len(m.trashList.Items()) > 0 {
+					m.state = confirmEmptyTrashView
+				}
+				return m, nil
+			}
+		case archiveView:
+			// Let the list handle keys while the filter input is active
+			if m.archiveList.FilterState() == list.Filtering {
+				break
+			}
+
+			switch {
+			case key.Matches(msg, m.archiveKeys.back):
+				// Return to main list
+				m.state = listView
+				return m, nil
+			case key.Matches(msg, m.archiveKeys.restore):
+				// Move the selected note back into the todo list
+				selected := m.archiveList.SelectedItem()
+				if selected == nil {
+					return m, nil
+				}
+				archived := selected.(archiveItem)
+				if err := m.restoreFromArchive(archived); err != nil {
+					if errors.Is(err, os.ErrExist) {
+						return m, m.listStatus(&m.archiveList, statusError, archived.filename+" already exists")
+					}
+					return m, m.listStatus(&m.archiveList, statusError, "Restore failed: "+err.Error())
+				}
+				cmd := m.archiveList.SetItems(m.loadArchive())
+				statusCmd := m.listStatus(&m.archiveList, statusNotice, "Restored "+archived.filename)
+				commitCmd := m.gitCommit("restore "+archived.filename, archived.filename, path.Join(archiveDirName, archived.filename))
+				return m, tea.Batch(cmd, statusCmd, commitCmd)
+			}
+		case confirmBulkView:
+			switch msg.String() {
+			case "y", "Y":
+				// Act on all marked todos, then reload once
+				m.state = todoListView
+				status, commitCmd := m.runBulkAction()
+				cmd := m.reloadTodoList()
+				return m, tea.Batch(cmd, m.listStatus(&m.todoList, statusNotice, status), commitCmd)
+			case "n", "N", "esc":
+				// Keep the marks and return to the list
+				m.state = todoListView
+				return m, nil
+			}
+			return m, nil
+		case confirmEmptyTrashView:
+			switch msg.String() {
+			case "y", "Y":
+				// Permanently delete the trashed files
+				m.state = trashView
+				n, err := m.emptyTrash()
+				cmd := m.trashList.SetItems(m.loadTrash())
+				if err != nil {
+					status := fmt.Sprintf("Empty trash failed after deleting %d file(s): %v", n, err)
+					return m, tea.Batch(cmd, m.listStatus(&m.trashList, statusError, status))
+				}
+				statusCmd := m.listStatus(&m.trashList, statusNotice, fmt.Sprintf("Permanently deleted %d file(s)", n))
+				return m, tea.Batch(cmd, statusCmd)
+			case "n", "N", "esc":
+				// Keep the trash and return to it
+				m.state = trashView
+				return m, nil
+			}
+			return m, nil
+		case replaceView:
+			switch msg.String() {
+			case "tab", "shift+tab", "up", "down":
+				// Move between the find and replace fields
+				if m.findInput.Focused() {
+					m.findInput.Blur()
+					m.replaceInput.Focus()
+				} else {
+					m.replaceInput.Blur()
+					m.findInput.Focus()
+				}
+				return m, textinput.Blink
+			case "enter":
+				// Replace every occurrence and return to the editor
+				if find := m.findInput.Value(); find != "" {
+					n := strings.Count(m.editor.Value(), find)
+					if n > 0 {
+						m.setEditorValue(strings.ReplaceAll(m.editor.Value(), find, m.replaceInput.Value()))
+					}
+					m.editorStatus = fmt.Sprintf("Replaced %d occurrence(s) of %q", n, find)
+				}
+				m.findInput.SetValue("")
+				m.replaceInput.SetValue("")
+				m.state = editorView
+				m.editor.Focus()
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			case "esc":
+				// Cancel and return to the editor
+				m.findInput.SetValue("")
+				m.replaceInput.SetValue("")
+				m.state = editorView
+				m.editor.Focus()
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			}
+		case searchView:
+			if m.searchInput.Focused() {
+				switch msg.String() {
+				case "enter":
+					// Stop typing and step through the matches
+					if len(m.searchMatches) == 0 {
+						m.editorStatus = fmt.Sprintf("No matches for %q", m.searchInput.Value())
+						return m, m.closeSearch(true)
+					}
+					m.searchInput.Blur()
+					return m, nil
+				case "esc":
+					// Cancel, putting the cursor back where it was
+					return m, m.closeSearch(true)
 				}
+				break
+			}
+			switch msg.String() {
+			case "n":
+				m.stepSearch(1)
+			case "N":
+				m.stepSearch(-1)
+			case "/":
+				// Change the query
+				m.searchInput.Focus()
+				return m, textinput.Blink
+			case "enter", "esc":
+				// Clear the highlights and edit at the current match
+				return m, m.closeSearch(false)
 			}
-		case createTodoView:
+			return m, nil
+		case gotoLineView:
 			switch msg.String() {
 			case "enter":
-				// Save the filename and switch to editor
-				fileName := m.textInput.Value()
-				if fileName != "" {
-					// Remove any file extension if user typed one
-					fileName = strings.TrimSuffix(fileName, filepath.Ext(fileName))
-
-					m.currentFile = fileName
-					m.textInput.SetValue("")
-					m.state = editorView
-					m.editor.Focus()
-					return m, textarea.Blink
+				// Move the cursor to the requested line, clamped to the buffer
+				input := strings.TrimSpace(m.lineInput.Value())
+				if line, err := strconv.Atoi(input); err == nil {
+					line = min(max(line, 1), m.editor.LineCount())
+					m.setEditorCursor(line-1, 0)
+					m.editorStatus = fmt.Sprintf("Line %d", line)
+				} else if input != "" {
+					m.editorStatus = fmt.Sprintf("%q is not a line number", input)
 				}
+				m.lineInput.SetValue("")
+				m.state = editorView
+				m.editor.Focus()
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			case "esc":
+				// Cancel and return to the editor
+				m.lineInput.SetValue("")
+				m.state = editorView
+				m.editor.Focus()
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			}
+		case exportAllView:
+			switch msg.String() {
+			case "tab":
+				m.exportSubfolders = !m.exportSubfolders
 				return m, nil
+			case "enter":
+				// Write the combined file and report back on the menu
+				out := strings.TrimSpace(m.textInput.Value())
+				if out == "" {
+					out = exportAllName(time.Now())
+				}
+				m.textInput.SetValue("")
+				return m, m.startExportAll(out, m.exportSubfolders)
 			case "esc":
-				// Cancel and return to list
 				m.textInput.SetValue("")
 				m.state = listView
 				return m, nil
 			}
-		case editorView:
+		case renameTagView:
 			switch msg.String() {
+			case "enter":
+				return m, m.submitRenameTag()
 			case "esc":
-				// Cancel and return to list without saving
-				m.editor.Reset()
-				m.state = listView
+				m.closeRenameTag()
 				return m, nil
-			case "ctrl+s":
-				// Save file and continue editing
-				if err := m.saveFile(); err != nil {
-					fmt.Println("Error saving file:", err)
-				}
+			}
+		case exportingView:
+			if msg.String() == "esc" {
+				// Stop after the note being written; the menu hears back
+				// once it has
+				m.cancelExportAll()
+			}
+			return m, nil
+		case tagFilterView:
+			switch msg.String() {
+			case "enter":
+				// Show only notes with the tag; an empty tag shows everything
+				m.tagFilter = strings.TrimPrefix(strings.TrimSpace(m.textInput.Value()), "#")
+				m.textInput.SetValue("")
+				m.state = todoListView
+				m.todoList.Title = m.todoListTitle()
+				return m, m.reloadTodoList()
+			case "esc":
+				// Cancel and return to the todo list
+				m.textInput.SetValue("")
+				m.state = todoListView
 				return m, nil
-			case "ctrl+d":
-				// Save file and return to list
-				if err := m.saveFile(); err != nil {
-					fmt.Println("Error saving file:", err)
-				}
-				m.editor.Reset()
+			}
+		case statsView:
+			if msg.String() == "esc" {
+				// Return to main list
 				m.state = listView
-				return m, nil
-			case "ctrl+p":
-				// Switch to preview
-				m.state = previewView
-				m.ready = false
-				return m, nil
 			}
-		case previewView:
+			return m, nil
+		case confirmDiscardView:
 			switch msg.String() {
-			case "esc", "q", "ctrl+p":
-				// Return to editor
+			case "y", "Y":
+				// Discard changes and return to list
+				return m, m.closeEditor()
+			case "n", "N", "esc":
+				// Keep editing
 				m.state = editorView
 				m.editor.Focus()
-				return m, textarea.Blink
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
 			}
-		case todoListView:
+			return m, nil
+		case confirmReloadView:
 			switch msg.String() {
-			case "esc":
-				// Return to main list
-				m.state = listView
+			case "y", "Y":
+				// Drop the changes for what's on disk
+				m.reloadNote()
+			case "n", "N", "esc":
+				// Keep editing
+			default:
 				return m, nil
-			case "ctrl+p":
-				// Open selected todo file in preview mode
-				selected := m.todoList.SelectedItem()
-				if selected != nil {
-					selectedTodo := selected.(todoItem)
-					fileName := strings.TrimSuffix(selectedTodo.filename, ".md")
-
-					// Load the file content
-					homeDir, err := os.UserHomeDir()
-					if err == nil {
-						filePath := filepath.Join(homeDir, "todo", selectedTodo.filename)
-						content, err := os.ReadFile(filePath)
-						if err == nil {
-							m.currentFile = fileName
-							m.editor.SetValue(string(content))
-							m.state = previewView
-							m.ready = false
-							return m, nil
-						}
-					}
+			}
+			m.state = editorView
+			m.editor.Focus()
+			return m, tea.Batch(textarea.Blink, m.startAutosave())
+		case confirmQuitView:
+			switch msg.String() {
+			case "y", "Y":
+				return m, tea.Quit
+			case "n", "N", "esc":
+				// Back to where quit was asked for
+				m.state = m.quitReturn
+				if m.state == editorView {
+					m.editor.Focus()
+					return m, tea.Batch(textarea.Blink, m.startAutosave())
 				}
-				return m, nil
+			}
+			return m, nil
+		case diffView:
+			if msg.String() == "esc" {
+				return m, m.closeDiff()
+			}
+		case renameNoteView:
+			switch msg.String() {
 			case "enter":
-				// Open selected todo file
-				selected := m.todoList.SelectedItem()
-				if selected != nil {
-					selectedTodo := selected.(todoItem)
-					fileName := strings.TrimSuffix(selectedTodo.filename, ".md")
+				return m, m.renameCurrentNote()
+			case "esc":
+				return m, m.closeRenameNote()
+			}
+		case confirmOverwriteView:
+			switch msg.String() {
+			case "o", "O":
+				return m, m.resolveSaveConflict(true)
+			case "r", "R":
+				return m, m.resolveSaveConflict(false)
+			case "c", "C", "esc":
+				// Keep editing without saving
+				m.state = editorView
+				m.editor.Focus()
+				return m, tea.Batch(textarea.Blink, m.startAutosave())
+			}
+			return m, nil
+		case confirmExistingView:
+			switch msg.String() {
+			case "y", "Y":
+				// Open the saved note instead of starting over
+				m.textInput.SetValue("")
+				cmd, err := m.openInEditor(m.createExisting)
+				if err != nil {
+					m.createStatus = "Open failed: " + err.Error()
+					m.state = createTodoView
+					return m, textinput.Blink
+				}
+				return m, cmd
+			case "n", "N", "esc":
+				// Go back to pick another name
+				m.state = createTodoView
+				return m, textinput.Blink
+			}
+			return m, nil
+		case renameTodoView:
+			switch msg.String() {
+			case "enter":
+				// Rename the file within the todo directory
+				if strings.TrimSpace(m.textInput.Value()) == "" {
+					return m, nil
+				}
+				oldName := m.renameTarget.filename
+				newName, err := renamedNote(oldName, m.textInput.Value())
 
-					// Load the file content
-					homeDir, err := os.UserHomeDir()
-					if err == nil {
-						filePath := filepath.Join(homeDir, "todo", selectedTodo.filename)
-						content, err := os.ReadFile(filePath)
-						if err == nil {
-							m.currentFile = fileName
-							m.editor.SetValue(string(content))
-							m.state = editorView
-							m.editor.Focus()
-							return m, textarea.Blink
-						}
-					}
+				m.renameTarget = todoItem{}
+				m.textInput.SetValue("")
+				m.state = todoListView
+
+				if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Rename failed: "+err.Error())
 				}
-				return m, nil
-			case "x", "backspace":
-				// Delete selected todo file
-				selected := m.todoList.SelectedItem()
-				if selected != nil {
-					selectedTodo := selected.(todoItem)
-					homeDir, err := os.UserHomeDir()
-					if err == nil {
-						filePath := filepath.Join(homeDir, "todo", selectedTodo.filename)
-						os.Remove(filePath)
-
-						// Reload the list
-						items := m.loadTodoFiles()
-						cmd := m.todoList.SetItems(items)
-						statusCmd := m.todoList.NewStatusMessage(statusMessageStyle("Deleted " + selectedTodo.filename))
-						return m, tea.Batch(cmd, statusCmd)
-					}
+				if newName == oldName {
+					return m, nil
+				}
+				if err := m.renameNote(oldName, newName); errors.Is(err, fs.ErrExist) {
+					return m, m.listStatus(&m.todoList, statusError, newName+" already exists")
+				} else if err != nil {
+					return m, m.listStatus(&m.todoList, statusError, "Rename failed: "+err.Error())
 				}
+
+				// Reload the list
+				cmd := m.reloadTodoList()
+				statusCmd := m.listStatus(&m.todoList, statusNotice, "Renamed "+oldName+" to "+newName)
+				commitCmd := m.gitCommit("rename "+oldName+" to "+newName, oldName, newName)
+				return m, tea.Batch(cmd, statusCmd, commitCmd)
+			case "esc":
+				// Cancel and return to the todo list
+				m.renameTarget = todoItem{}
+				m.textInput.SetValue("")
+				m.state = todoListView
 				return m, nil
 			}
 		}
 
+	case tea.MouseMsg:
+		// Click to select a list item, click it again to open it
+		if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+			break
+		}
+		var open bool
+		switch m.state {
+		case listView:
+			open = clickList(&m.mainList, msg.Y)
+		case todoListView:
+			open = clickList(&m.todoList, msg.Y)
+		}
+		if open {
+			if enter, ok := keyMsgFor(m.delegateKeys.choose.Keys()[0]); ok {
+				return m.Update(enter)
+			}
+		}
+
+	case externalEditorFinishedMsg:
+		// Pick up whatever the external editor wrote
+		if msg.err != nil {
+			m.editorStatus = "External editor failed: " + msg.err.Error()
+			return m, nil
+		}
+		content, err := os.ReadFile(m.currentFilePath())
+		if err != nil {
+			m.editorStatus = "Reload failed: " + err.Error()
+			return m, nil
+		}
+		row, col := m.editorCursor()
+		m.loadEditor(string(content))
+		m.setEditorCursor(row, col)
+		m.editorStatus = "Reloaded from $EDITOR"
+		return m, nil
+
+	case splitRefreshMsg:
+		// Only the last refresh scheduled while typing renders
+		if msg.id == m.splitRefreshID && m.splitActive() && m.state != previewView {
+			m.refreshPreview()
+		}
+		return m, nil
+
+	case gitCommitMsg:
+		// Report failed auto-commits where the user is looking
+		if msg.err != nil {
+			log.Printf("git commit failed: %v", msg.err)
+			status := "Git commit failed: " + msg.err.Error()
+			if m.state == todoListView {
+				return m, m.listStatus(&m.todoList, statusError, status)
+			}
+			m.editorStatus = status
+		}
+		return m, nil
+
+	case trashPurgedMsg:
+		return m, m.trashPurgedStatus(msg)
+
+	case recurringDoneMsg:
+		return m, m.recurringStatus(msg)
+
+	case undoDeleteExpiredMsg:
+		// Forget the stash unless a newer delete replaced it
+		if m.lastDeleted != nil && m.lastDeleted.id == msg.id {
+			m.lastDeleted = nil
+		}
+		return m, nil
+
+	case linkOpenedMsg:
+		if m.state == previewView {
+			m.previewStatus = linkOpenedStatus(msg)
+		}
+		return m, nil
+
+	case exportAllProgressMsg:
+		m.exportDone, m.exportTotal = msg.done, msg.total
+		return m, m.waitExportAll()
+
+	case tagRenamedMsg:
+		logOp("rename tag", "#"+msg.from+" to #"+msg.to, msg.err)
+		return m, m.tagRenamed(msg)
+
+	case exportAllDoneMsg:
+		logOp("export all to", msg.path, msg.err)
+		m.cancelExportAll()
+		m.exportUpdates = nil
+		if m.state == exportingView {
+			m.state = listView
+		}
+		if errors.Is(msg.err, context.Canceled) {
+			return m, m.listStatus(&m.mainList, statusInfo, "Export cancelled")
+		}
+		if msg.err != nil {
+			return m, m.listStatus(&m.mainList, statusError, "Export failed: "+msg.err.Error())
+		}
+		return m, m.listStatus(&m.mainList, statusNotice, exportAllStatus(msg.path, msg.count, msg.skipped))
+
+	case todosLoadedMsg:
+		return m, m.todosLoaded(msg)
+
+	case todayLoadedMsg:
+		m.todayLoading = false
+		m.loadErr = msg.err
+		h, v := docStyle.GetFrameSize()
+		m.todayList.SetSize(m.width-h, m.height-v-m.loadErrHeight())
+		return m, m.todayList.SetItems(msg.tasks)
+
+	case statsLoadedMsg:
+		m.statsLoading = false
+		if msg.err != nil {
+			if m.state == statsView {
+				m.state = listView
+			}
+			return m, m.listStatus(&m.mainList, statusError, "Can't read todos: "+msg.err.Error())
+		}
+		m.stats = msg.stats
+		return m, nil
+
+	case filesChangedMsg:
+		// Refresh the todo list when notes change outside the app
+		cmds = append(cmds, m.watcher.wait())
+		if m.state == todoListView && m.todoList.FilterState() != list.Filtering {
+			cmds = append(cmds, m.reloadTodoList())
+		}
+		return m, tea.Batch(cmds...)
+
+	case autosaveTickMsg:
+		// Drop ticks from stale timers or once the editor has been left
+		if msg.id != m.autosaveID || m.state != editorView {
+			return m, nil
+		}
+		if m.dirty {
+			if m.lastErr = m.saveFile(); m.lastErr == nil {
+				m.autosaved = true
+				return m, tea.Batch(m.startAutosave(), m.commitCurrentFile())
+			}
+		}
+		return m, m.startAutosave()
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -384,43 +2480,52 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.mainList.SetSize(msg.Width-h, msg.Height-v)
 
 		if m.state == todoListView {
-			m.todoList.SetSize(msg.Width-h, msg.Height-v)
+			m.sizeTodoList()
+		}
+		if m.state == trashView {
+			m.trashList.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.state == paletteView {
+			m.paletteList.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.state == recentView {
+			m.recentList.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.state == todayView {
+			m.todayList.SetSize(msg.Width-h, msg.Height-v-m.loadErrHeight())
+		}
+		if m.state == templateView {
+			m.templateList.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.state == archiveView {
+			m.archiveList.SetSize(msg.Width-h, msg.Height-v)
+		}
+		if m.state == workspaceView {
+			m.workspaceList.SetSize(msg.Width-h, msg.Height-v)
 		}
 
 		// Size the editor to fit the screen (accounting for help text)
-		m.editor.SetWidth(msg.Width - h)
-		titleHeight := lipgloss.Height(appTitleStyle.Render("Todo App"))
-		m.editor.SetHeight(msg.Height - v - 6 - titleHeight)
+		m.sizeEditor()
+		if m.state == diffView {
+			m.sizeDiff()
+		}
+
+		// The split preview takes whatever the editor leaves
+		if m.splitActive() && m.state != previewView {
+			m.initSplitPreview()
+		}
 
 		// Handle viewport sizing for preview
 		if m.state == previewView {
-			// Account for app title, pager header, footer, help text and margins
-			appTitleHeight := lipgloss.Height(appTitleStyle.Render("Todo App"))
-			headerHeight := lipgloss.Height(m.previewHeaderView())
-			footerHeight := lipgloss.Height(m.previewFooterView())
-			helpHeight := 2    // Help text height
-			marginsHeight := 4 // Top and bottom margins (1, 2)
-
-			verticalMarginHeight := appTitleHeight + headerHeight + footerHeight + helpHeight + marginsHeight
-
 			if !m.ready {
-				// Render markdown content
-				content := m.editor.Value()
-				if content == "" {
-					content = "# Empty Document\n\nStart typing to see content here."
-				}
-				rendered, err := glamour.Render(content, "dark")
-				if err != nil {
-					rendered = content
-				}
-
-				m.viewport = viewport.New(msg.Width-h, msg.Height-verticalMarginHeight)
-				m.viewport.YPosition = headerHeight
-				m.viewport.SetContent(rendered)
-				m.ready = true
+				m.initPreview()
 			} else {
-				m.viewport.Width = msg.Width - h
-				m.viewport.Height = msg.Height - verticalMarginHeight
+				m.viewport.Width = m.previewWidth()
+				m.viewport.Height = msg.Height - m.previewVerticalMargin()
+				if !m.previewNoWrap {
+					// Re-wrap to the new width
+					m.refreshPreview()
+				}
 			}
 		}
 	}
@@ -429,15 +2534,62 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m.state {
 	case listView:
 		m.mainList, cmd = m.mainList.Update(msg)
-	case createTodoView:
+	case createTodoView, renameTodoView, renameNoteView, tagFilterView, exportAllView, renameTagView:
 		m.textInput, cmd = m.textInput.Update(msg)
+	case searchView:
+		query := m.searchInput.Value()
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		if m.searchInput.Value() != query {
+			m.updateSearch()
+		}
+	case gotoLineView:
+		m.lineInput, cmd = m.lineInput.Update(msg)
+	case replaceView:
+		var findCmd, replaceCmd tea.Cmd
+		m.findInput, findCmd = m.findInput.Update(msg)
+		m.replaceInput, replaceCmd = m.replaceInput.Update(msg)
+		cmd = tea.Batch(findCmd, replaceCmd)
 	case editorView:
-		m.editor, cmd = m.editor.Update(msg)
+		if keyMsg, ok := msg.(tea.KeyMsg); ok && m.readOnly {
+			// Only let through keys that move around
+			if m.movesCursor(keyMsg) {
+				m.editor, cmd = m.editor.Update(msg)
+			}
+		} else if ok {
+			before := m.snapshot(m.editor.Value())
+			m.editor, cmd = m.editor.Update(msg)
+			if after := m.editor.Value(); after != before.value {
+				m.recordTyping(before, keyMsg)
+				m.dirty = true
+				m.autosaved = false
+				m.editorStatus = ""
+				m.counts = countText(after)
+			}
+		} else {
+			m.editor, cmd = m.editor.Update(msg)
+		}
 	case previewView:
 		m.viewport, cmd = m.viewport.Update(msg)
 		cmds = append(cmds, cmd)
+	case diffView:
+		m.diffViewport, cmd = m.diffViewport.Update(msg)
 	case todoListView:
 		m.todoList, cmd = m.todoList.Update(msg)
+		m.todoList.Title = m.todoListTitle()
+	case trashView:
+		m.trashList, cmd = m.trashList.Update(msg)
+	case paletteView:
+		m.paletteList, cmd = m.paletteList.Update(msg)
+	case recentView:
+		m.recentList, cmd = m.recentList.Update(msg)
+	case todayView:
+		m.todayList, cmd = m.todayList.Update(msg)
+	case templateView:
+		m.templateList, cmd = m.templateList.Update(msg)
+	case archiveView:
+		m.archiveList, cmd = m.archiveList.Update(msg)
+	case workspaceView:
+		m.workspaceList, cmd = m.workspaceList.Update(msg)
 	}
 
 	if len(cmds) > 0 {
@@ -446,32 +2598,105 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// commitCurrentFile auto-commits the file open in the editor.
+func (m *model) commitCurrentFile() tea.Cmd {
+	filename := m.currentFileName()
+	return m.gitCommit("update "+filename, filename)
+}
+
+// currentFilePath returns the path of the file open in the editor.
+func (m model) currentFilePath() string {
+	return filepath.Join(m.todoDir, m.currentFileName())
+}
+
+// saveFile writes the editor buffer to the note it was opened from.
 func (m *model) saveFile() error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return err
+	err := m.writeNote()
+	logOp("save", m.currentFileName(), err)
+	if err == nil {
+		m.index.refresh(m.currentFileName())
+		m.saveIndex()
+	}
+	return err
+}
+
+func (m *model) writeNote() error {
+	if !filepath.IsLocal(m.currentFileName()) {
+		return fmt.Errorf("%s is outside the todo directory", m.currentFileName())
 	}
+	filePath := m.currentFilePath()
 
-	todoDir := filepath.Join(homeDir, "todo")
+	info, err := os.Stat(filePath)
+	if err == nil && !info.ModTime().Equal(m.diskModTime) {
+		return errChangedOnDisk
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		// Notes made elsewhere keep their names, but new ones must be safe
+		if err := checkNoteName(m.currentFileName()); err != nil {
+			return fmt.Errorf("can't save as %s: %w", m.currentFileName(), err)
+		}
+	}
 
-	// Create the todo directory if it doesn't exist
-	if err := os.MkdirAll(todoDir, 0755); err != nil {
+	// Create the todo directory, and any subfolders in the name, if they
+	// don't exist
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
 		return err
 	}
+	content := m.editor.Value()
+	// Frontmatter is a markdown convention, so plain text is left alone
+	stamped := content
+	if isMarkdown(m.currentFileName()) {
+		stamped = stampFrontmatter(content, time.Now(), errors.Is(err, fs.ErrNotExist))
+	}
+	if stamped != content {
+		// Stamp the buffer as one undoable edit, keeping the cursor on the
+		// same text when a new block is prepended
+		row, col := m.editorCursor()
+		added := strings.Count(stamped, "\n") - strings.Count(content, "\n")
+		m.setEditorValue(stamped)
+		m.setEditorCursor(row+added, col)
+		content = stamped
+	}
 
-	filePath := filepath.Join(todoDir, m.currentFile+".md")
-
-	return os.WriteFile(filePath, []byte(m.editor.Value()), 0644)
+	data := []byte(content)
+	if m.encrypted {
+		if m.cipher == nil {
+			return errNoPassphrase
+		}
+		if data, err = m.cipher.encrypt(data); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return err
+	}
+	m.diskModTime = m.noteModTime()
+	m.meta = parseMeta(content)
+	m.dirty = false
+	return nil
 }
 
 func (m model) previewHeaderView() string {
-	title := previewTitleStyle.Render(m.currentFile + ".md")
+	title := previewTitleStyle.Render(m.currentFileName())
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(title)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, title, line)
 }
 
 func (m model) previewFooterView() string {
-	info := previewInfoStyle.Render(fmt.Sprintf("%3.f%%", m.viewport.ScrollPercent()*100))
+	mode := "markdown"
+	if !isMarkdown(m.currentFileName()) {
+		mode = "text"
+	} else if m.rawPreview {
+		mode = "raw"
+	}
+	wrap := "wrap"
+	if m.previewNoWrap {
+		wrap = "no wrap"
+	}
+	if side := m.previewSideIndicator(); side != "" {
+		wrap += " " + side
+	}
+	info := previewInfoStyle.Render(fmt.Sprintf("%s · %s · %s · %3.f%%", mode, wrap, m.lintSummary(), m.viewport.ScrollPercent()*100))
 	line := strings.Repeat("─", max(0, m.viewport.Width-lipgloss.Width(info)))
 	return lipgloss.JoinHorizontal(lipgloss.Center, line, info)
 }
@@ -493,32 +2718,300 @@ func (m model) View() string {
 			m.textInput.View(),
 		)
 		help := helpStyle.Render("(enter to continue, esc to cancel)")
+		if m.createStatus != "" {
+			help += "\n" + statusMessageStyle(m.createStatus)
+		}
 		return docStyle.Render(content + "\n\n" + help)
-	case editorView:
+	case editorView, searchView, renameNoteView:
 		appTitle := appTitleStyle.Render("Todo App")
-		header := fmt.Sprintf("\n  Editing: %s.md\n\n", m.currentFile)
-		help := helpStyle.Render("ctrl+p: preview | esc: cancel | ctrl+d: save & exit | ctrl+s: save")
-		content := appTitle + header + m.editor.View() + "\n\n" + help
+		header := fmt.Sprintf("\n  Editing: %s", m.currentFileName())
+		if m.state == renameNoteView {
+			header = "\n  Rename to: " + m.textInput.View()
+		}
+		if m.dirty {
+			// Unsaved changes, cleared by the next save or autosave
+			header += dirtyMarkerStyle.Render(" ●")
+		}
+		if m.readOnly {
+			header += readOnlyStyle.Render(" [read-only]")
+		}
+		if meta := m.meta.String(); meta != "" {
+			header += noteMetaStyle.Render("  (" + meta + ")")
+		}
+		header += "\n\n"
+		var info []string
+		if m.autosaved {
+			info = append(info, "autosaved")
+		}
+		if m.editorStatus != "" {
+			info = append(info, m.editorStatus)
+		}
+		info = append(info, m.counts.String())
+		infoText := " | " + strings.Join(info, " | ")
+		bar := m.taskProgressView()
+		if bar != "" {
+			bar = "  " + bar
+		}
+		// Cut the key hints short rather than the status, so the footer
+		// stays on one line
+		h, _ := docStyle.GetFrameSize()
+		keysWidth := max(m.width-h-lipgloss.Width(infoText)-lipgloss.Width(bar), 0)
+		keysText := fitHelp(m.editorHelpText(), keysWidth)
+		if keysText == "" {
+			infoText = strings.TrimPrefix(infoText, " | ")
+		}
+		help := helpStyle.Render(keysText+infoText) + bar
+		if m.lastErr != nil {
+			help += "\n" + errorMessageStyle.Render("Error saving file: "+m.lastErr.Error())
+		}
+		editor := m.editorView()
+		if m.state == searchView {
+			help = helpStyle.Render(m.searchHelpText())
+			gutter := m.editor.FocusedStyle.Base.GetBorderLeftSize() + m.editorGutter()
+			editor = highlightMatches(editor, m.searchInput.Value(), gutter)
+		}
+		if m.zen {
+			var footer string
+			switch {
+			case m.state == searchView:
+				footer = help
+			case m.state == renameNoteView:
+				footer = "Rename to: " + m.textInput.View()
+			case m.lastErr != nil:
+				footer = errorMessageStyle.Render("Error saving file: " + m.lastErr.Error())
+			case m.editorStatus != "":
+				footer = helpStyle.Render(m.editorStatus)
+			}
+			return m.zenView(editor, footer)
+		}
+		if m.splitActive() {
+			editor = m.splitEditorView(editor)
+		}
+		content := appTitle + header + editor + "\n\n" + help
 		return docStyle.Render(content)
 	case previewView:
 		if !m.ready {
 			return "\n  Initializing preview..."
 		}
 		appTitle := appTitleStyle.Render("Todo App")
-		previewContent := fmt.Sprintf("%s\n%s\n%s", m.previewHeaderView(), m.viewport.View(), m.previewFooterView())
-		help := helpStyle.Render("↑/↓: scroll | g/G: top/bottom | ctrl+u/d: half page | ctrl+p/q/esc: back to editor")
+		body := m.viewport.View()
+		if m.tocVisible {
+			body = lipgloss.JoinHorizontal(lipgloss.Top, body, m.tocView())
+		}
+		if m.lintVisible {
+			body += "\n" + m.lintView()
+		}
+		previewContent := fmt.Sprintf("%s\n%s\n%s", m.previewHeaderView(), body, m.previewFooterView())
+		help := helpStyle.Render(m.previewHelpText())
+		if m.previewStatus != "" {
+			help += "\n" + statusMessageStyle(m.previewStatus)
+		}
 		return docStyle.Render(appTitle + "\n" + previewContent + "\n" + help)
 	case todoListView:
-		return docStyle.Render(m.todoList.View())
+		todos := m.withLoadErr(m.todoList.View())
+		if m.todosLoading && len(m.todoList.Items()) == 0 {
+			todos = "Loading todos..."
+		}
+		if m.todoFolder != "" {
+			todos = m.breadcrumbView() + "\n" + todos
+		}
+		if m.peekActive() {
+			return docStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top, todos, m.peekView()))
+		}
+		return docStyle.Render(todos)
+	case trashView:
+		return docStyle.Render(m.trashList.View())
+	case confirmBulkView:
+		verb := "Move %d marked file(s) to the trash? (y/n)"
+		if m.bulkAction == bulkArchive {
+			verb = "Archive %d marked file(s)? (y/n)"
+		}
+		content := fmt.Sprintf(verb, len(m.marked))
+		help := helpStyle.Render("(y to confirm, n/esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case confirmEmptyTrashView:
+		content := fmt.Sprintf("Permanently delete %d file(s) in the trash? (y/n)", len(m.trashList.Items()))
+		help := helpStyle.Render("(y to delete, n/esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case helpView:
+		return docStyle.Render(m.helpView())
+	case paletteView:
+		return docStyle.Render(m.paletteList.View())
+	case recentView:
+		return docStyle.Render(m.recentList.View())
+	case todayView:
+		if m.todayLoading {
+			return docStyle.Render("Loading today's tasks...")
+		}
+		return docStyle.Render(m.withLoadErr(m.todayList.View()))
+	case templateView:
+		return docStyle.Render(m.templateList.View())
+	case archiveView:
+		return docStyle.Render(m.archiveList.View())
+	case workspaceView:
+		return docStyle.Render(m.workspaceList.View())
+	case statsView:
+		if m.statsLoading {
+			return docStyle.Render("Loading statistics...")
+		}
+		return docStyle.Render(m.statsView())
+	case tagFilterView:
+		content := fmt.Sprintf("Filter by tag:\n\n%s", m.textInput.View())
+		help := helpStyle.Render("(enter to filter, empty to show all, esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case exportingView:
+		return m.exportingView()
+	case renameTagView:
+		content := fmt.Sprintf("Tag to rename:\n\n%s", m.textInput.View())
+		help := "(enter to continue, esc to cancel)"
+		if m.retagFrom != "" {
+			content = fmt.Sprintf("Rename #%s in every note to:\n\n%s", m.retagFrom, m.textInput.View())
+			help = "(enter to rename, esc to cancel)"
+		}
+		if m.retagStatus != "" {
+			content += "\n\n" + errorMessageStyle.Render(m.retagStatus)
+		}
+		return docStyle.Render(content + "\n\n" + helpStyle.Render(help))
+	case exportAllView:
+		subfolders := "[ ]"
+		if m.exportSubfolders {
+			subfolders = "[x]"
+		}
+		content := fmt.Sprintf("Export all todos to:\n\n%s\n\n%s include subfolders", m.textInput.View(), subfolders)
+		help := helpStyle.Render("(enter to export, tab to toggle subfolders, esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case gotoLineView:
+		content := fmt.Sprintf(
+			"Go to line (1-%d):\n\n%s",
+			m.editor.LineCount(),
+			m.lineInput.View(),
+		)
+		help := helpStyle.Render("(enter to jump, esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case replaceView:
+		content := fmt.Sprintf(
+			"Find:\n\n%s\n\nReplace with:\n\n%s",
+			m.findInput.View(),
+			m.replaceInput.View(),
+		)
+		help := helpStyle.Render("(tab to switch fields, enter to replace all, esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
+	case confirmExistingView:
+		content := fmt.Sprintf("%s exists — open it instead? (y/n)", m.createExisting)
+		help := helpStyle.Render("(y to open it, n/esc to choose another name)")
+		return docStyle.Render(content + "\n\n" + help)
+	case diffView:
+		return m.diffPageView()
+	case confirmOverwriteView:
+		content := fmt.Sprintf("%s changed on disk since you opened it.\n\nOverwrite it with your version, reload it, or cancel? (o/r/c)", m.currentFileName())
+		help := helpStyle.Render("(o to overwrite, r to reload — ctrl+z gets your version back, c/esc to keep editing)")
+		return docStyle.Render(content + "\n\n" + help)
+	case confirmDiscardView:
+		content := fmt.Sprintf("Discard changes to %s? (y/n)", m.currentFileName())
+		help := helpStyle.Render("(y to discard, n/esc to keep editing)")
+		return docStyle.Render(content + "\n\n" + help)
+	case confirmReloadView:
+		content := fmt.Sprintf("Reload %s from disk, dropping unsaved changes? (y/n)", m.currentFileName())
+		help := helpStyle.Render("(y to reload, n/esc to keep editing)")
+		return docStyle.Render(content + "\n\n" + help)
+	case confirmQuitView:
+		content := "Unsaved changes — quit anyway? (y/n)"
+		help := helpStyle.Render("(y or ctrl+c to quit, n/esc to go back)")
+		return docStyle.Render(content + "\n\n" + help)
+	case renameTodoView:
+		content := fmt.Sprintf(
+			"Rename %s to:\n\n%s",
+			m.renameTarget.filename,
+			m.textInput.View(),
+		)
+		help := helpStyle.Render("(enter to rename, esc to cancel)")
+		return docStyle.Render(content + "\n\n" + help)
 	default:
 		return ""
 	}
 }
 
 func main() {
+	addText := flag.String("add", "", "append `text` as a new task and exit without starting the TUI")
+	addFile := flag.String("file", "inbox", "note to append to with --add")
+	newNote := flag.Bool("new", false, "save standard input as a new note, named by the first argument or today's date, and exit")
+	flag.Parse()
+	noteExtensions = noteExtensionsFromEnv()
+
+	logFile, err := startLog()
+	if err != nil {
+		fmt.Println("Warning: not logging:", err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	defaultDir, err := resolveTodoDir()
+	if err != nil {
+		log.Printf("resolving todo directory failed: %v", err)
+		fmt.Println("Error resolving todo directory:", err)
+		os.Exit(1)
+	}
+
+	// Work in whichever workspace was active last time
+	workspaces := []workspace{{name: defaultWorkspace, dir: defaultDir}}
+	if path, err := workspaceConfigPath(); err == nil {
+		workspaces, err = loadWorkspaces(path, defaultDir)
+		if err != nil {
+			log.Printf("ignoring workspaces: %v", err)
+			fmt.Println("Warning: ignoring workspaces:", err)
+		}
+	}
+	active := findWorkspace(workspaces, loadState(defaultDir).Workspace)
+	todoDir := active.dir
+
+	// Quick capture from the shell
+	if *addText != "" {
+		filename, err := appendTask(todoDir, *addFile, *addText)
+		logOp("add task to", *addFile, err)
+		if err != nil {
+			fmt.Println("Error adding task:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Added to", filename)
+		return
+	}
+
+	// Save piped text as a note
+	if *newNote {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Println("Error reading standard input:", err)
+			os.Exit(1)
+		}
+		filename, err := createNote(todoDir, flag.Arg(0), string(content), noteCipherFromEnv())
+		logOp("create from stdin", filename, err)
+		if err != nil {
+			fmt.Println("Error creating note:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Created", filename)
+		return
+	}
+
+	// Style the app before anything picks up the default styles
+	if path, err := themeConfigPath(); err == nil {
+		if err := loadTheme(path); err != nil {
+			log.Printf("ignoring theme: %v", err)
+			fmt.Println("Warning: ignoring theme:", err)
+		}
+	}
+
 	items := []list.Item{
 		item{title: "Create Todo", desc: "add a new todo item"},
 		item{title: "List All Todos", desc: "see all your todos"},
+		item{title: "Today", desc: "open tasks due or tagged #today"},
+		item{title: "Statistics", desc: "see an overview of your todos"},
+		item{title: "Export All", desc: "combine every todo into one markdown file"},
+		item{title: "Archive", desc: "browse and restore archived todos"},
+		item{title: "Trash", desc: "restore deleted todos"},
+		item{title: "Workspaces", desc: "switch to another todo directory"},
+		item{title: "Rename Tag", desc: "rename a #tag in every note"},
 	}
 
 	// Initialize text input
@@ -527,23 +3020,125 @@ func main() {
 	ti.CharLimit = 156
 	ti.Width = 50
 
+	findInput := textinput.New()
+	findInput.Placeholder = "text to find"
+	findInput.Width = 50
+
+	replaceInput := textinput.New()
+	replaceInput.Placeholder = "replacement"
+	replaceInput.Width = 50
+
+	lineInput := textinput.New()
+	lineInput.Placeholder = "line number"
+	lineInput.CharLimit = 9
+	lineInput.Width = 20
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "/"
+	searchInput.Placeholder = "search"
+	searchInput.Width = 30
+
 	delegateKeys := newDelegateKeyMap()
 	todoListKeys := newTodoListKeyMap()
+	trashKeys := newTrashKeyMap()
+	archiveKeys := newArchiveKeyMap()
+	editorKeys := newEditorKeyMap()
+	previewKeys := newPreviewKeyMap()
+	globalKeys := newGlobalKeyMap()
 
 	m := model{
 		mainList:     list.New(items, list.NewDefaultDelegate(), 0, 0),
 		textInput:    ti,
+		findInput:    findInput,
+		replaceInput: replaceInput,
+		lineInput:    lineInput,
+		searchInput:  searchInput,
 		editor:       newTextarea(),
 		state:        listView,
+		todoDir:      todoDir,
+		previewTheme: previewThemeFromEnv(),
+		codeStyle:    codeStyleFromEnv(),
 		delegateKeys: delegateKeys,
 		todoListKeys: todoListKeys,
+		trashKeys:    trashKeys,
+		archiveKeys:  archiveKeys,
+		editorKeys:   editorKeys,
+		previewKeys:  previewKeys,
+		globalKeys:   globalKeys,
+	}
+	m.workspaces = workspaces
+	m.workspace = active.name
+	m.mainList.Title = m.mainMenuTitle()
+	m.gitAutoCommit = gitAutoCommitFromEnv()
+	m.openAtEnd = openAtEndFromEnv()
+	m.dateFormat, m.dateTimeFormat = dateFormatsFromEnv()
+	m.reflowWidth = reflowWidthFromEnv()
+	m.tabWidth = tabWidthFromEnv()
+	if err := tabModeFromEnv(); err != nil {
+		log.Print(err)
+		fmt.Println("Warning:", err)
+	}
+	m.emoji = emojiFromEnv()
+	m.namePattern = namePatternFromEnv()
+	m.editedWindow = editedWindowFromEnv()
+	m.cipher = noteCipherFromEnv()
+	if indexFromEnv() {
+		m.index = openIndex(todoDir)
+	}
+	m.statusLifetimes = statusLifetimesFromEnv()
+	m.taskProgress = newTaskProgress()
+
+	// Apply the user's key bindings before anything shows them
+	if path, err := keyConfigPath(); err == nil {
+		warnings, err := m.loadKeyConfig(path)
+		if err != nil {
+			log.Printf("ignoring key bindings: %v", err)
+			fmt.Println("Warning: ignoring key bindings:", err)
+		}
+		for _, warning := range warnings {
+			log.Print(warning)
+			fmt.Println("Warning:", warning)
+		}
+	}
+
+	if path, err := emojiConfigPath(); err == nil && m.emoji {
+		if err := loadEmojiConfig(path); err != nil {
+			log.Printf("ignoring emoji shortcodes: %v", err)
+			fmt.Println("Warning: ignoring emoji shortcodes:", err)
+		}
 	}
-	m.mainList.Title = "Todo App"
+
+	// Restore where the previous session left off
+	state := loadState(todoDir)
+	m.restoreState(state)
+	m.menuUses = state.MenuUses
+	sortMenuByUse(items, m.menuUses)
+	m.mainList.SetItems(items)
+	if state.TodoListOpen {
+		m.startupCmd = m.openTodoList()
+	}
+	m.startupCmd = tea.Batch(m.startupCmd, m.startTrashPurge(), m.startRecurring())
+
+	// Keep the list current when notes change outside the app
+	watcher, err := watchTodoDir(todoDir)
+	if err != nil {
+		log.Printf("not watching for changes: %v", err)
+		fmt.Println("Warning: not watching for changes:", err)
+	}
+	m.watcher = watcher
 
 	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 
-	if _, err := p.Run(); err != nil {
+	final, err := p.Run()
+	if err != nil {
+		log.Printf("program failed: %v", err)
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
+	final.(model).watcher.Close()
+
+	if err := final.(model).saveState(); err != nil {
+		log.Printf("saving state failed: %v", err)
+		fmt.Println("Error saving state:", err)
+	}
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// checkboxPattern matches a GitHub-style task list item, capturing the
+// indentation and bullet, the box state and the rest of the line.
+var checkboxPattern = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])(\].*)$`)
+
+// tagPattern matches a #hashtag at the start of a line or after
+// whitespace, so headings ("# Title") and URL fragments aren't tags.
+var tagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// toggleCheckbox flips the checkbox on line, preserving indentation. The
+// second return value is false when the line isn't a checkbox.
+func toggleCheckbox(line string) (string, bool) {
+	match := checkboxPattern.FindStringSubmatch(line)
+	if match == nil {
+		return line, false
+	}
+
+	state := "x"
+	if match[2] != " " {
+		state = " "
+	}
+	return match[1] + state + match[3], true
+}
+
+// countCheckboxes returns how many task list items in content are checked
+// and how many there are in total.
+func countCheckboxes(content string) (done, total int) {
+	// Most notes are short, but skip the line scan entirely when there
+	// can't be any checkboxes.
+	if !strings.Contains(content, "[") {
+		return 0, 0
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		match := checkboxPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		total++
+		if match[2] != " " {
+			done++
+		}
+	}
+	return done, total
+}
+
+// extractTags returns the distinct #tags in content, without the #, in
+// order of first appearance.
+func extractTags(content string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, match := range tagPattern.FindAllStringSubmatch(content, -1) {
+		tag := match[1]
+		if key := strings.ToLower(tag); !seen[key] {
+			seen[key] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// hasTag reports whether tags contains tag, ignoring case.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// sortMenuByUse orders the main menu's items most used first, keeping the
+// default order between items used equally often.
+func sortMenuByUse(items []list.Item, uses map[string]int) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return uses[items[i].(item).title] > uses[items[j].(item).title]
+	})
+}
+
+// countMenuUse records that the main menu entry title was chosen. The
+// menu is only reordered by it next session, so entries don't move
+// around under the cursor.
+func (m *model) countMenuUse(title string) {
+	if m.menuUses == nil {
+		m.menuUses = make(map[string]int)
+	}
+	m.menuUses[title]++
+}
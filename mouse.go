@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listIndexAt maps a terminal row to the index of the list item drawn
+// there, accounting for docStyle's margin and the list's title and
+// status bar. The second return value is false for rows between or
+// outside of items.
+func listIndexAt(l list.Model, y int) (int, bool) {
+	top := docStyle.GetMarginTop()
+	if l.ShowTitle() {
+		top += lipgloss.Height(l.Styles.TitleBar.Render(l.Styles.Title.Render(l.Title)))
+	}
+	if l.ShowStatusBar() {
+		top += lipgloss.Height(l.Styles.StatusBar.Render(""))
+	}
+
+	row := y - top
+	if row < 0 {
+		return 0, false
+	}
+
+	d := list.NewDefaultDelegate()
+	slot := d.Height() + d.Spacing()
+	if row%slot >= d.Height() {
+		return 0, false
+	}
+
+	offset := row / slot
+	if offset >= l.Paginator.ItemsOnPage(len(l.VisibleItems())) {
+		return 0, false
+	}
+	return l.Paginator.Page*l.Paginator.PerPage + offset, true
+}
+
+// clickList selects the item under a click, reporting whether it was
+// already selected so the caller can treat the click as an open.
+func clickList(l *list.Model, y int) (open bool) {
+	if l.FilterState() == list.Filtering {
+		return false
+	}
+	index, ok := listIndexAt(*l, y)
+	if !ok {
+		return false
+	}
+	if index == l.Index() {
+		return true
+	}
+	l.Select(index)
+	return false
+}
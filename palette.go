@@ -0,0 +1,237 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var paletteViewNames = map[viewState]string{
+	listView:     "main menu",
+	todoListView: "todo list",
+	trashView:    "trash",
+	editorView:   "editor",
+	previewView:  "preview",
+	archiveView:  "archive",
+}
+
+// paletteItem is an action offered by the command palette. Running it
+// replays key in the view it belongs to, so it goes down exactly the same
+// path as pressing the key there. Main menu entries set menu instead.
+type paletteItem struct {
+	title string
+	state viewState
+	key   string
+	menu  string
+}
+
+func (i paletteItem) Title() string { return i.title }
+
+func (i paletteItem) Description() string {
+	if i.menu != "" {
+		return paletteViewNames[listView]
+	}
+	return paletteViewNames[i.state] + " · " + i.key
+}
+
+func (i paletteItem) FilterValue() string { return i.title }
+
+// paletteEntry builds an item that triggers b in state.
+func paletteEntry(title string, state viewState, b key.Binding) paletteItem {
+	return paletteItem{title: title, state: state, key: b.Keys()[0]}
+}
+
+// paletteItems lists the actions available from view.
+func (m model) paletteItems(view viewState) []list.Item {
+	var items []list.Item
+	add := func(entries ...paletteItem) {
+		for _, e := range entries {
+			items = append(items, e)
+		}
+	}
+
+	switch view {
+	case editorView:
+		keys := m.editorKeys
+		add(
+			paletteEntry("Preview", view, keys.preview),
+			paletteEntry("Toggle Task", view, keys.toggleTask),
+			paletteEntry("Find and Replace", view, keys.replace),
+			paletteEntry("Search", view, keys.search),
+			paletteEntry("Go to Line", view, keys.gotoLine),
+			paletteEntry("Open in $EDITOR", view, keys.external),
+			paletteEntry("Undo", view, keys.undo),
+			paletteEntry("Redo", view, keys.redo),
+			paletteEntry("Toggle Word Wrap", view, keys.wrap),
+			paletteEntry("Toggle Split Preview", view, keys.split),
+			paletteEntry("Insert Date", view, keys.date),
+			paletteEntry("Insert Date and Time", view, keys.dateTime),
+			paletteEntry("Duplicate Line", view, keys.dupLine),
+			paletteEntry("Move Line Up", view, keys.lineUp),
+			paletteEntry("Move Line Down", view, keys.lineDown),
+			paletteEntry("Next Open Task", view, keys.nextTask),
+			paletteEntry("Previous Open Task", view, keys.prevTask),
+			paletteEntry("Reflow Paragraph", view, keys.reflow),
+			paletteEntry("Format Table", view, keys.table),
+			paletteEntry("Dedent Line", view, keys.dedent),
+			paletteEntry("Toggle Read-only", view, keys.readOnly),
+			paletteEntry("Reload from Disk", view, keys.reload),
+			paletteEntry("Toggle Zen Mode", view, keys.zen),
+			paletteEntry("Rename Note", view, keys.rename),
+			paletteEntry("Show Changes Since Save", view, keys.diff),
+			paletteEntry("Save", view, keys.save),
+			paletteEntry("Save and Exit", view, keys.saveExit),
+			paletteEntry("Close Editor", view, keys.cancel),
+		)
+	case previewView:
+		keys := m.previewKeys
+		add(
+			paletteEntry("Toggle Theme", view, keys.theme),
+			paletteEntry("Toggle Line Numbers", view, keys.lineNums),
+			paletteEntry("Toggle Raw Markdown", view, keys.raw),
+			paletteEntry("Toggle Word Wrap", view, keys.wrap),
+			paletteEntry("Toggle Table of Contents", view, keys.toc),
+			paletteEntry("Toggle Markdown Warnings", view, keys.lint),
+			paletteEntry("Open Link in View", view, keys.openLink),
+			paletteEntry("Export HTML", view, keys.export),
+			paletteEntry("Copy Markdown", view, keys.copy),
+			paletteEntry("Copy as Text", view, keys.copyText),
+			paletteEntry("Back to Editor", view, keys.back),
+		)
+	case todoListView:
+		keys := m.todoListKeys
+		add(
+			paletteEntry("Open Todo", view, m.delegateKeys.choose),
+			paletteEntry("New Todo Here", view, keys.newTodo),
+			paletteEntry("Preview Todo", view, keys.preview),
+			paletteEntry("Rename Todo", view, keys.rename),
+			paletteEntry("Duplicate Todo", view, keys.duplicate),
+			paletteEntry("Export HTML", view, keys.export),
+			paletteEntry("Copy Markdown", view, keys.copy),
+			paletteEntry("Copy as Text", view, keys.copyText),
+			paletteEntry("Pin or Unpin Todo", view, keys.pin),
+			paletteEntry("Cycle Color Label", view, keys.label),
+			paletteEntry("Filter by Label", view, keys.byLabel),
+			paletteEntry("Filter by Completion", view, keys.byDone),
+			paletteEntry("Toggle Side Preview", view, keys.peek),
+			paletteEntry("Up a Folder", view, keys.parent),
+			paletteEntry("Archive Todo", view, keys.archive),
+			paletteEntry("Mark Todo", view, keys.mark),
+			paletteEntry("Trash Marked Todos", view, keys.bulkTrash),
+			paletteEntry("Archive Marked Todos", view, keys.bulkArch),
+			paletteEntry("Filter by Tag", view, keys.tag),
+			paletteEntry("Change Sort Order", view, keys.sort),
+			paletteEntry("Move Todo to Trash", view, m.delegateKeys.remove),
+		)
+	case trashView:
+		add(
+			paletteEntry("Restore from Trash", view, m.trashKeys.restore),
+			paletteEntry("Empty Trash", view, m.trashKeys.empty),
+		)
+	case archiveView:
+		add(paletteEntry("Restore from Archive", view, m.archiveKeys.restore))
+	}
+
+	// Leaving through the main menu would drop an open buffer, so those
+	// entries are only offered outside the editor
+	if view != editorView && view != previewView {
+		add(
+			paletteItem{title: "New Todo", menu: "Create Todo"},
+			paletteItem{title: "List All Todos", menu: "List All Todos"},
+			paletteItem{title: "Today's Tasks", menu: "Today"},
+			paletteItem{title: "Statistics", menu: "Statistics"},
+			paletteItem{title: "Export All Todos", menu: "Export All"},
+			paletteItem{title: "Open Archive", menu: "Archive"},
+			paletteItem{title: "Open Trash", menu: "Trash"},
+			paletteItem{title: "Switch Workspace", menu: "Workspaces"},
+			paletteItem{title: "Rename Tag", menu: "Rename Tag"},
+			paletteEntry("Recent Files", listView, m.delegateKeys.recent),
+			paletteEntry("Open Scratchpad", listView, m.delegateKeys.scratch),
+		)
+	}
+	add(paletteItem{title: "Help", state: view, key: helpPaletteKey(m.globalKeys.help)})
+	return items
+}
+
+// helpPaletteKey picks the help key to replay, preferring one that isn't a
+// printable character since those are typed as text in the editor.
+func helpPaletteKey(b key.Binding) string {
+	for _, k := range b.Keys() {
+		if len([]rune(k)) > 1 {
+			return k
+		}
+	}
+	return b.Keys()[0]
+}
+
+// openPalette shows the command palette for the current view, with the
+// filter already focused so typing narrows it down straight away.
+func (m *model) openPalette() {
+	m.prevState = m.state
+	m.paletteList = list.New(m.paletteItems(m.state), list.NewDefaultDelegate(), 0, 0)
+	m.paletteList.Title = "Commands"
+	m.paletteList.Styles.Title = todoTitleStyle
+	m.paletteList.SetShowHelp(false)
+
+	h, v := docStyle.GetFrameSize()
+	m.paletteList.SetSize(m.width-h, m.height-v)
+	// Run the empty filter first so every command is listed, rather than
+	// none until something is typed
+	m.paletteList.SetFilterText("")
+	m.paletteList.SetFilterState(list.Filtering)
+	m.state = paletteView
+}
+
+// closePalette returns to the view the palette was opened from.
+func (m *model) closePalette() tea.Cmd {
+	m.state = m.prevState
+	if m.state == editorView {
+		m.editor.Focus()
+		return tea.Batch(textarea.Blink, m.startAutosave())
+	}
+	return nil
+}
+
+// runPaletteItem performs the selected palette action.
+func (m model) runPaletteItem(action paletteItem) (tea.Model, tea.Cmd) {
+	cmd := m.closePalette()
+	m.state = action.state
+
+	if action.menu != "" {
+		m.state = listView
+		for i, it := range m.mainList.Items() {
+			if it.(item).title == action.menu {
+				m.mainList.Select(i)
+			}
+		}
+		action.key = "enter"
+	}
+
+	msg, ok := keyMsgFor(action.key)
+	if !ok {
+		return m, cmd
+	}
+	next, actionCmd := m.Update(msg)
+	return next, tea.Batch(cmd, actionCmd)
+}
+
+// keyMsgFor builds the key message that msg.String() would report as s.
+func keyMsgFor(s string) (tea.KeyMsg, bool) {
+	if rest, ok := strings.CutPrefix(s, "alt+"); ok {
+		msg, ok := keyMsgFor(rest)
+		msg.Alt = true
+		return msg, ok
+	}
+	if runes := []rune(s); len(runes) == 1 {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: runes}, true
+	}
+	for t := tea.KeyType(-128); t < 128; t++ {
+		if t != tea.KeyRunes && (tea.Key{Type: t}).String() == s {
+			return tea.KeyMsg{Type: t}, true
+		}
+	}
+	return tea.KeyMsg{}, false
+}
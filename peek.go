@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// peekHeadLines is how much of a note the todo list's side panel reads.
+const peekHeadLines = 100
+
+// peekMinWidth is the narrowest terminal the side panel is shown in.
+const peekMinWidth = 80
+
+var peekStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), false, false, false, true).
+	BorderForeground(lipgloss.Color("238")).
+	PaddingLeft(1)
+
+// peekEntry is the cached head of a note, read when it was last modified
+// at modified.
+type peekEntry struct {
+	modified time.Time
+	lines    []string
+	err      error
+}
+
+// peekActive reports whether the todo list has its side panel.
+func (m model) peekActive() bool {
+	return m.peekVisible && m.width >= peekMinWidth
+}
+
+// peekWidth is the width the side panel takes from the todo list.
+func (m model) peekWidth() int {
+	if !m.peekActive() {
+		return 0
+	}
+	h, _ := docStyle.GetFrameSize()
+	return (m.width - h) * 2 / 5
+}
+
+// sizeTodoList fits the todo list to whatever the side panel leaves.
+func (m *model) sizeTodoList() {
+	h, v := docStyle.GetFrameSize()
+	m.todoList.SetSize(m.width-h-m.peekWidth(), m.height-v-m.breadcrumbHeight()-m.loadErrHeight())
+}
+
+// selectedTodo returns the highlighted note in the todo list.
+func (m model) selectedTodo() (todoItem, bool) {
+	todo, ok := m.todoList.SelectedItem().(todoItem)
+	return todo, ok
+}
+
+// loadPeek reads the head of the highlighted note for the side panel,
+// unless it's cached and the note hasn't changed since.
+func (m *model) loadPeek() {
+	todo, ok := m.selectedTodo()
+	if !ok {
+		return
+	}
+	if entry, ok := m.peekCache[todo.filename]; ok && entry.modified.Equal(todo.modified) {
+		return
+	}
+
+	entry := peekEntry{modified: todo.modified}
+	content, err := m.readNote(todo.filename)
+	if err != nil {
+		entry.err = err
+	} else {
+		body := strings.TrimLeft(stripFrontmatter(string(content)), "\n")
+		entry.lines = strings.SplitN(strings.ReplaceAll(body, "\t", "    "), "\n", peekHeadLines+1)
+		if len(entry.lines) > peekHeadLines {
+			entry.lines = entry.lines[:peekHeadLines]
+		}
+	}
+	if m.peekCache == nil {
+		m.peekCache = make(map[string]peekEntry)
+	}
+	m.peekCache[todo.filename] = entry
+}
+
+// peekHeight is the number of note lines the side panel shows.
+func (m model) peekHeight() int {
+	_, v := docStyle.GetFrameSize()
+	return m.height - v
+}
+
+// scrollPeek scrolls the side panel by delta lines, starting from the
+// top whenever the selection has moved to another note.
+func (m *model) scrollPeek(delta int) {
+	todo, ok := m.selectedTodo()
+	if !ok {
+		return
+	}
+	if todo.filename != m.peekFile {
+		m.peekFile = todo.filename
+		m.peekOffset = 0
+	}
+	lines := len(m.peekCache[todo.filename].lines)
+	m.peekOffset = min(max(m.peekOffset+delta, 0), max(lines-m.peekHeight(), 0))
+}
+
+// peekView renders the head of the highlighted note beside the list.
+func (m model) peekView() string {
+	width := m.peekWidth() - peekStyle.GetHorizontalFrameSize()
+	height := m.peekHeight()
+
+	var head []string
+	if todo, ok := m.selectedTodo(); ok {
+		entry := m.peekCache[todo.filename]
+		offset := 0
+		if todo.filename == m.peekFile {
+			offset = m.peekOffset
+		}
+		switch {
+		case entry.err != nil:
+			head = []string{lineNumberStyle.Render(entry.err.Error())}
+		case offset < len(entry.lines):
+			head = entry.lines[offset:]
+		}
+	}
+
+	var lines []string
+	for _, line := range head[:min(len(head), height)] {
+		lines = append(lines, ansi.Truncate(line, width, "…"))
+	}
+	return peekStyle.Width(width + 1).Height(height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var lineNumberStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// previewThemes are the glamour styles the preview can cycle through.
+var previewThemes = []string{
+	styles.DarkStyle,
+	styles.LightStyle,
+	styles.NoTTYStyle,
+	styles.AutoStyle,
+}
+
+// previewThemeFromEnv returns the theme named by $GOTODO_THEME, or dark
+// when it's unset or unknown.
+func previewThemeFromEnv() string {
+	theme := os.Getenv("GOTODO_THEME")
+	for _, t := range previewThemes {
+		if t == theme {
+			return theme
+		}
+	}
+	return styles.DarkStyle
+}
+
+// codeStyleFromEnv returns the chroma style named by $GOTODO_CODE_STYLE
+// for code blocks, or "" to keep the preview theme's own colours.
+func codeStyleFromEnv() string {
+	name := os.Getenv("GOTODO_CODE_STYLE")
+	if _, ok := chromastyles.Registry[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+func nextPreviewTheme(theme string) string {
+	for i, t := range previewThemes {
+		if t == theme {
+			return previewThemes[(i+1)%len(previewThemes)]
+		}
+	}
+	return previewThemes[0]
+}
+
+// glamourStyle resolves the preview theme to a concrete glamour style.
+// Auto uses lipgloss's background detection rather than letting glamour
+// query the terminal while the program owns it.
+func (m model) glamourStyle() string {
+	if m.previewTheme != styles.AutoStyle {
+		return m.previewTheme
+	}
+	if lipgloss.HasDarkBackground() {
+		return styles.DarkStyle
+	}
+	return styles.LightStyle
+}
+
+// glamourStyleOption picks the renderer style. A custom code style is
+// applied on top of a copy of the standard theme; glamour only honours
+// the chroma theme name when the style has no colours of its own.
+func (m model) glamourStyleOption() glamour.TermRendererOption {
+	base, ok := styles.DefaultStyles[m.glamourStyle()]
+	if m.codeStyle == "" || !ok {
+		return glamour.WithStandardStyle(m.glamourStyle())
+	}
+	style := *base
+	style.CodeBlock.Theme = m.codeStyle
+	style.CodeBlock.Chroma = nil
+	return glamour.WithStyles(style)
+}
+
+// renderedPreview reports whether the preview renders markdown, rather
+// than showing the note verbatim because it's in raw mode or not markdown.
+func (m model) renderedPreview() bool {
+	return !m.rawPreview && isMarkdown(m.currentFileName())
+}
+
+// renderPreview renders the editor content for the preview, as markdown
+// or verbatim in raw mode and for plain text notes. Frontmatter is shown
+// as a box of fields above the rendered markdown; a block that doesn't
+// parse is rendered along with the rest. Line numbers are only added to
+// the verbatim view, where they match the editor's.
+func (m model) renderPreview() string {
+	content := m.editor.Value()
+	if content == "" {
+		content = "# Empty Document\n\nStart typing to see content here."
+	}
+
+	rendered := content
+	if m.renderedPreview() {
+		lines, body, _ := splitFrontmatter(content)
+		rendered = m.renderMarkdown(body)
+		if box := frontmatterBox(lines, m.viewport.Width); box != "" {
+			rendered = "\n" + box + "\n" + rendered
+		}
+	} else if m.previewLineNumbers {
+		rendered = numberLines(rendered)
+	}
+	return rendered
+}
+
+// renderMarkdown renders content with glamour, falling back to the
+// source when rendering fails.
+func (m model) renderMarkdown(content string) string {
+	// A wrap width of zero turns glamour's word wrapping off entirely
+	width := m.viewport.Width
+	if m.previewNoWrap {
+		width = 0
+	}
+	r, err := glamour.NewTermRenderer(m.glamourStyleOption(), glamour.WithWordWrap(width))
+	if err != nil {
+		return content
+	}
+	rendered, err := r.Render(content)
+	if err != nil {
+		return content
+	}
+	return rendered
+}
+
+// numberLines prefixes each line of s with its line number.
+func numberLines(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	width := len(strconv.Itoa(len(lines)))
+	for i, line := range lines {
+		lines[i] = lineNumberStyle.Render(fmt.Sprintf("%*d ", width, i+1)) + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// previewHorizontalStep is how many columns left/right scroll the
+// preview when lines aren't wrapped.
+const previewHorizontalStep = 4
+
+// previewVerticalMargin returns the rows taken by everything around the
+// preview viewport.
+func (m model) previewVerticalMargin() int {
+	// Account for app title, pager header, footer, help text and margins
+	appTitleHeight := lipgloss.Height(appTitleStyle.Render("Todo App"))
+	headerHeight := lipgloss.Height(m.previewHeaderView())
+	footerHeight := lipgloss.Height(m.previewFooterView())
+	helpHeight := 2    // Help text height
+	marginsHeight := 4 // Top and bottom margins (1, 2)
+
+	lintHeight := 0
+	if m.lintVisible {
+		lintHeight = lipgloss.Height(m.lintView())
+	}
+	return appTitleHeight + headerHeight + footerHeight + helpHeight + marginsHeight + lintHeight
+}
+
+// openPreview switches to the preview, rendering it straight away when
+// the window size is already known.
+func (m *model) openPreview() {
+	m.state = previewView
+	m.previewStatus = ""
+	m.ready = false
+	if m.width > 0 {
+		m.initPreview()
+	}
+}
+
+// initPreview builds the preview viewport for the current window size.
+func (m *model) initPreview() {
+	// The warnings panel's height depends on them, so find them first
+	m.lint = m.noteWarnings()
+	m.viewport = viewport.New(m.previewWidth(), m.height-m.previewVerticalMargin())
+	m.viewport.YPosition = lipgloss.Height(m.previewHeaderView())
+	m.viewport.SetHorizontalStep(previewHorizontalStep)
+	m.refreshPreview()
+	m.viewport.SetYOffset(m.previewOffsets[m.currentFile])
+	m.selectHeadingAt(m.viewport.YOffset)
+	m.ready = true
+}
+
+// previewWidth is the width left for the preview viewport beside the
+// table of contents.
+func (m model) previewWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	return m.width - h - m.tocWidth()
+}
+
+// refreshPreview re-renders the preview in place, keeping the scroll
+// position, and finds the headings again in the new rendering.
+func (m *model) refreshPreview() {
+	rendered := m.renderPreview()
+	yOffset := m.viewport.YOffset
+	m.viewport.SetContent(rendered)
+	m.viewport.SetYOffset(yOffset)
+	m.previewWidest = 0
+	for _, line := range strings.Split(rendered, "\n") {
+		m.previewWidest = max(m.previewWidest, ansi.StringWidth(line))
+	}
+
+	source := m.editor.Value()
+	if m.renderedPreview() {
+		source = stripFrontmatter(source)
+	}
+	m.toc = parseHeadings(source)
+	locateHeadings(m.toc, rendered)
+	m.tocSelected = min(m.tocSelected, max(len(m.toc)-1, 0))
+	m.lint = m.noteWarnings()
+}
+
+// rememberPreviewOffset records the scroll position of the current file
+// so reopening its preview picks up where it was left.
+func (m *model) rememberPreviewOffset() {
+	if m.previewOffsets == nil {
+		m.previewOffsets = make(map[string]int)
+	}
+	m.previewOffsets[m.currentFile] = m.viewport.YOffset
+}
+
+// previewSideIndicator shows which sides of the preview have more of it
+// off screen: "◀" for the left, "▶" for the right.
+func (m model) previewSideIndicator() string {
+	if m.previewWidest <= m.viewport.Width {
+		return ""
+	}
+	percent := m.viewport.HorizontalScrollPercent()
+	left, right := " ", " "
+	if percent > 0 {
+		left = "◀"
+	}
+	if percent < 1 {
+		right = "▶"
+	}
+	return left + right
+}
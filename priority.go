@@ -0,0 +1,81 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// priority ranks a note in the backlog. Notes without one are
+// priorityNone and sort after all the rest.
+type priority int
+
+const (
+	priorityNone priority = iota
+	priorityLow
+	priorityMedium
+	priorityHigh
+)
+
+// priorityLinePattern matches a "priority: <level>" line in the body of
+// a note.
+var priorityLinePattern = regexp.MustCompile(`(?mi)^\s*priority:\s*(\S.*?)\s*$`)
+
+var priorityColors = map[priority]lipgloss.Color{
+	priorityHigh:   lipgloss.Color("#FF5F87"),
+	priorityMedium: lipgloss.Color("#FFD75F"),
+	priorityLow:    lipgloss.Color("#5FAFFF"),
+}
+
+func (p priority) String() string {
+	switch p {
+	case priorityHigh:
+		return "high"
+	case priorityMedium:
+		return "medium"
+	case priorityLow:
+		return "low"
+	default:
+		return ""
+	}
+}
+
+// marker is the colored dot shown before the names of notes with a
+// priority.
+func (p priority) marker() string {
+	if p == priorityNone {
+		return ""
+	}
+	return lipgloss.NewStyle().Foreground(priorityColors[p]).Render("●") + " "
+}
+
+// parsePriorityLevel reads a priority as written in a note: high, medium
+// or low, their first letters, or 1 to 3 with 1 the highest.
+func parsePriorityLevel(s string) priority {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "high", "h", "1":
+		return priorityHigh
+	case "medium", "med", "m", "2":
+		return priorityMedium
+	case "low", "l", "3":
+		return priorityLow
+	default:
+		return priorityNone
+	}
+}
+
+// parsePriority returns the priority of a note, from its frontmatter or
+// else the first "priority:" line.
+func parsePriority(content string) priority {
+	lines, body, _ := splitFrontmatter(content)
+	if v, ok := frontmatterField(lines, "priority"); ok {
+		if p := parsePriorityLevel(v); p != priorityNone {
+			return p
+		}
+	}
+	if match := priorityLinePattern.FindStringSubmatch(body); match != nil {
+		return parsePriorityLevel(match[1])
+	}
+	return priorityNone
+}
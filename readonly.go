@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toggleReadOnly switches the editor between editing and just reading
+// the note.
+func (m *model) toggleReadOnly() {
+	m.readOnly = !m.readOnly
+	if m.readOnly {
+		m.editorStatus = "Read-only"
+	} else {
+		m.editorStatus = "Editing"
+	}
+}
+
+// editsBuffer reports whether msg is one of the editor commands that
+// change the note, which read-only mode turns away.
+func (m model) editsBuffer(msg tea.KeyMsg) bool {
+	keys := m.editorKeys
+	return key.Matches(msg, keys.save, keys.saveExit, keys.toggleTask, keys.replace,
+		keys.undo, keys.redo, keys.date, keys.dateTime, keys.reflow, keys.table, keys.external,
+		keys.dupLine, keys.lineUp, keys.lineDown, keys.indent, keys.dedent)
+}
+
+// movesCursor reports whether msg only moves the textarea cursor, so it
+// can still be used while read-only.
+func (m model) movesCursor(msg tea.KeyMsg) bool {
+	keys := m.editor.KeyMap
+	return key.Matches(msg, keys.CharacterForward, keys.CharacterBackward,
+		keys.WordForward, keys.WordBackward, keys.LineNext, keys.LinePrevious,
+		keys.LineStart, keys.LineEnd, keys.InputBegin, keys.InputEnd)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recentLimit is how many recently opened files are remembered.
+const recentLimit = 10
+
+// pushRecent moves filename to the front of the recent files.
+func (m *model) pushRecent(filename string) {
+	recent := []string{filename}
+	for _, f := range m.recent {
+		if f != filename && len(recent) < recentLimit {
+			recent = append(recent, f)
+		}
+	}
+	m.recent = recent
+}
+
+// dropRecent forgets filename, e.g. once it turns out to be gone.
+func (m *model) dropRecent(filename string) {
+	recent := m.recent[:0]
+	for _, f := range m.recent {
+		if f != filename {
+			recent = append(recent, f)
+		}
+	}
+	m.recent = recent
+}
+
+func (m *model) recentItems() []list.Item {
+	items := make([]list.Item, 0, len(m.recent))
+	for _, filename := range m.recent {
+		desc := "missing"
+		if info, err := os.Stat(filepath.Join(m.todoDir, filename)); err == nil {
+			desc = "Modified: " + info.ModTime().Format("Jan 02, 2006 3:04 PM")
+		}
+		items = append(items, item{title: filename, desc: desc})
+	}
+	return items
+}
+
+// openRecent lists the recently opened files, most recent first.
+func (m *model) openRecent() {
+	m.recentList = list.New(m.recentItems(), list.NewDefaultDelegate(), 0, 0)
+	m.recentList.Title = "Recent Files"
+	m.recentList.Styles.Title = todoTitleStyle
+
+	h, v := docStyle.GetFrameSize()
+	m.recentList.SetSize(m.width-h, m.height-v)
+
+	m.state = recentView
+}
+
+// openInEditor loads filename, relative to the todo directory, into the
+// editor and records it as recently opened.
+func (m *model) openInEditor(filename string) (tea.Cmd, error) {
+	content, err := m.readNote(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	m.currentFile = noteName(filename)
+	m.currentExt = baseExt(filename)
+	m.encrypted = isEncrypted(filename)
+	m.loadEditor(string(content))
+	m.pushRecent(filename)
+	m.state = editorView
+	m.editor.Focus()
+	return tea.Batch(textarea.Blink, m.startAutosave()), nil
+}
+
+// openRecentItem opens the selected recent file, quietly dropping it from
+// the list if it was deleted behind our back.
+func (m *model) openRecentItem(filename string) tea.Cmd {
+	cmd, err := m.openInEditor(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		m.dropRecent(filename)
+		setCmd := m.recentList.SetItems(m.recentItems())
+		statusCmd := m.listStatus(&m.recentList, statusError, filename+" no longer exists")
+		return tea.Batch(setCmd, statusCmd)
+	}
+	if err != nil {
+		return m.listStatus(&m.recentList, statusError, "Open failed: "+err.Error())
+	}
+	return cmd
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// repeatLinePattern matches a "repeat: <how often>" line in the body of
+// a note.
+var repeatLinePattern = regexp.MustCompile(`(?mi)^\s*repeat:\s*(\S.*?)\s*$`)
+
+// repeatHeadingPattern matches the heading a recurring checklist is added
+// under, capturing its date.
+var repeatHeadingPattern = regexp.MustCompile(`^## (\d{4}-\d{2}-\d{2})\s*$`)
+
+// generatedField is the frontmatter field recording when a recurring
+// note's checklist was last added.
+const generatedField = "generated"
+
+// repeatFromField is the frontmatter field recording the date a recurring
+// note's occurrences are counted from. Counting from the last generated
+// date instead would let a monthly note started on the 31st drift to the
+// 28th after February.
+const repeatFromField = "repeat-from"
+
+// recurringDoneMsg reports the recurring notes brought up to date at
+// startup.
+type recurringDoneMsg struct {
+	count int
+	err   error
+}
+
+// parseRepeat returns how often a note repeats, from its frontmatter or
+// else the first "repeat:" line: "daily", "weekly", "monthly" or "".
+func parseRepeat(content string) string {
+	lines, body, _ := splitFrontmatter(content)
+	v, ok := frontmatterField(lines, "repeat")
+	if !ok {
+		if match := repeatLinePattern.FindStringSubmatch(body); match != nil {
+			v = match[1]
+		}
+	}
+	switch v = strings.ToLower(v); v {
+	case "daily", "weekly", "monthly":
+		return v
+	}
+	return ""
+}
+
+// occurrence returns the nth time a note repeating every repeat comes
+// round after start. Monthly notes keep to start's day of the month, or
+// the last day in shorter months.
+func occurrence(start time.Time, repeat string, n int) time.Time {
+	switch repeat {
+	case "daily":
+		return start.AddDate(0, 0, n)
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	}
+	y, mo, d := start.Date()
+	first := time.Date(y, mo+time.Month(n), 1, 0, 0, 0, 0, start.Location())
+	return first.AddDate(0, 0, min(d, first.AddDate(0, 1, -1).Day())-1)
+}
+
+// latestOccurrence returns the last time on or before today that a note
+// repeating from anchor came round, and false if that was no later than
+// last, when it was last generated.
+func latestOccurrence(anchor, last, today time.Time, repeat string) (time.Time, bool) {
+	latest := anchor
+	for n := 1; ; n++ {
+		next := occurrence(anchor, repeat, n)
+		if next.After(today) {
+			return latest, latest.After(last)
+		}
+		latest = next
+	}
+}
+
+// recurringTasks returns the tasks to repeat, unchecked: those under the
+// latest dated heading, or all of them before the first one is added.
+func recurringTasks(body string) []string {
+	lines := strings.Split(body, "\n")
+	start := 0
+	for i, line := range lines {
+		if repeatHeadingPattern.MatchString(line) {
+			start = i + 1
+		}
+	}
+	var tasks []string
+	for _, line := range lines[start:] {
+		if match := checkboxPattern.FindStringSubmatch(line); match != nil {
+			tasks = append(tasks, match[1]+" "+match[3])
+		}
+	}
+	return tasks
+}
+
+// setFrontmatterField sets key to value in content's frontmatter, adding
+// a block if there isn't one.
+func setFrontmatterField(content, key, value string) string {
+	eol := lineEnding(content)
+	lines, body, ok := splitFrontmatter(content)
+	if !ok {
+		return frontmatterDelim + eol + key + ": " + value + eol + frontmatterDelim + eol + content
+	}
+	set := false
+	for i, line := range lines {
+		if k, _, found := strings.Cut(line, ":"); found && strings.TrimSpace(k) == key {
+			lines[i] = key + ": " + value
+			set = true
+			break
+		}
+	}
+	if !set {
+		lines = append(lines, key+": "+value)
+	}
+	return frontmatterDelim + eol + strings.Join(lines, eol) + eol + frontmatterDelim + eol + body
+}
+
+// repeatNote brings a recurring note up to date as of today, adding a
+// fresh checklist under a dated heading if it has come round since it
+// was last generated. A note that's never been generated is only
+// stamped, since it already holds the first checklist. It reports
+// whether content changed and whether a checklist was added.
+func repeatNote(content string, today time.Time) (updated string, changed, added bool) {
+	repeat := parseRepeat(content)
+	if repeat == "" {
+		return content, false, false
+	}
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	lines, body, _ := splitFrontmatter(content)
+	v, _ := frontmatterField(lines, generatedField)
+	last, err := time.ParseInLocation(time.DateOnly, v, today.Location())
+	if err != nil {
+		stamp := today.Format(time.DateOnly)
+		updated = setFrontmatterField(content, repeatFromField, stamp)
+		return setFrontmatterField(updated, generatedField, stamp), true, false
+	}
+	// Notes stamped before the anchor was kept count from when they were
+	// last generated
+	anchor := last
+	if v, ok := frontmatterField(lines, repeatFromField); ok {
+		if t, err := time.ParseInLocation(time.DateOnly, v, today.Location()); err == nil {
+			anchor = t
+		}
+	}
+	latest, due := latestOccurrence(anchor, last, today, repeat)
+	tasks := recurringTasks(body)
+	if !due || len(tasks) == 0 {
+		return content, false, false
+	}
+	date := latest.Format(time.DateOnly)
+	updated = strings.TrimRight(content, "\n") + "\n\n## " + date + "\n\n" + strings.Join(tasks, "\n") + "\n"
+	updated = setFrontmatterField(updated, repeatFromField, anchor.Format(time.DateOnly))
+	return setFrontmatterField(updated, generatedField, date), true, true
+}
+
+// generateRecurring brings every recurring note in dir up to date,
+// returning how many got a new checklist. Encrypted notes are left
+// alone.
+func generateRecurring(dir string, now time.Time) (int, error) {
+	added := 0
+	err := filepath.WalkDir(dir, func(path string, file fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if file.IsDir() {
+			if path != dir && (strings.HasPrefix(file.Name(), ".") || path == filepath.Join(dir, archiveDirName)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isNoteFile(file.Name()) || isEncrypted(file.Name()) {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(strings.ToLower(string(content)), "repeat:") {
+			return nil
+		}
+		updated, changed, isNew := repeatNote(string(content), now)
+		if !changed {
+			return nil
+		}
+		err = os.WriteFile(path, []byte(updated), 0644)
+		logOp("repeat", filepath.Base(path), err)
+		if err == nil && isNew {
+			added++
+		}
+		return nil
+	})
+	return added, err
+}
+
+// startRecurring brings recurring notes up to date in the background.
+func (m *model) startRecurring() tea.Cmd {
+	dir := m.todoDir
+	return func() tea.Msg {
+		count, err := generateRecurring(dir, time.Now())
+		return recurringDoneMsg{count: count, err: err}
+	}
+}
+
+// recurringStatus reports new recurring checklists on whichever list is
+// showing.
+func (m *model) recurringStatus(msg recurringDoneMsg) tea.Cmd {
+	if msg.count == 0 && msg.err == nil {
+		return nil
+	}
+	l := &m.mainList
+	if m.state == todoListView {
+		l = &m.todoList
+	} else if m.state != listView {
+		return nil
+	}
+	if msg.err != nil {
+		return m.listStatus(l, statusError, "Updating recurring notes failed: "+msg.err.Error())
+	}
+	return m.listStatus(l, statusNotice, fmt.Sprintf("Added %d recurring checklist(s)", msg.count))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// mustDate parses a YYYY-MM-DD date in the local zone, for test tables.
+func mustDate(s string) time.Time {
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		start, repeat string
+		n             int
+		want          string
+	}{
+		{"2026-01-30", "daily", 3, "2026-02-02"},
+		{"2026-12-31", "daily", 1, "2027-01-01"},
+		{"2026-12-29", "weekly", 1, "2027-01-05"},
+		{"2028-02-26", "weekly", 1, "2028-03-04"},
+		{"2026-01-31", "monthly", 1, "2026-02-28"},
+		{"2026-01-31", "monthly", 2, "2026-03-31"},
+		{"2026-01-31", "monthly", 3, "2026-04-30"},
+		{"2028-01-31", "monthly", 1, "2028-02-29"},
+		{"2026-12-31", "monthly", 1, "2027-01-31"},
+		{"2026-11-30", "monthly", 3, "2027-02-28"},
+		{"2026-03-15", "monthly", 12, "2027-03-15"},
+	}
+	for _, tt := range tests {
+		got := occurrence(mustDate(tt.start), tt.repeat, tt.n).Format(time.DateOnly)
+		if got != tt.want {
+			t.Errorf("occurrence(%s, %s, %d) = %s, want %s", tt.start, tt.repeat, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLatestOccurrence(t *testing.T) {
+	tests := []struct {
+		anchor, last, today, repeat string
+		want                        string
+		due                         bool
+	}{
+		{"2026-01-31", "2026-01-31", "2026-02-27", "monthly", "2026-01-31", false},
+		{"2026-01-31", "2026-01-31", "2026-02-28", "monthly", "2026-02-28", true},
+		{"2026-01-31", "2026-02-28", "2026-03-30", "monthly", "2026-02-28", false},
+		{"2026-01-31", "2026-02-28", "2026-03-31", "monthly", "2026-03-31", true},
+		{"2028-01-31", "2028-01-31", "2028-02-29", "monthly", "2028-02-29", true},
+		{"2026-12-31", "2026-12-31", "2027-01-31", "monthly", "2027-01-31", true},
+		{"2026-12-31", "2026-12-31", "2027-03-05", "monthly", "2027-02-28", true},
+		{"2026-12-28", "2026-12-28", "2027-01-03", "weekly", "2026-12-28", false},
+		{"2026-12-28", "2026-12-28", "2027-01-04", "weekly", "2027-01-04", true},
+		{"2026-12-31", "2026-12-31", "2027-01-01", "daily", "2027-01-01", true},
+		{"2026-12-31", "2027-01-01", "2027-01-01", "daily", "2027-01-01", false},
+	}
+	for _, tt := range tests {
+		got, due := latestOccurrence(mustDate(tt.anchor), mustDate(tt.last), mustDate(tt.today), tt.repeat)
+		if got.Format(time.DateOnly) != tt.want || due != tt.due {
+			t.Errorf("latestOccurrence(%s, %s, %s, %s) = %s, %v, want %s, %v",
+				tt.anchor, tt.last, tt.today, tt.repeat, got.Format(time.DateOnly), due, tt.want, tt.due)
+		}
+	}
+}
+
+func TestRepeatNote(t *testing.T) {
+	const body = "# Bills\n\nrepeat: monthly\n\n- [x] rent\n- [ ] power\n"
+	tests := []struct {
+		name, content, today string
+		changed, added       bool
+		want                 []string
+	}{
+		{
+			name:    "first run only stamps",
+			content: body,
+			today:   "2026-01-31",
+			changed: true,
+			want:    []string{"repeat-from: 2026-01-31", "generated: 2026-01-31"},
+		},
+		{
+			name:    "not due yet",
+			content: "---\nrepeat-from: 2026-01-31\ngenerated: 2026-01-31\n---\n" + body,
+			today:   "2026-02-27",
+		},
+		{
+			name:    "clamped to the end of February",
+			content: "---\nrepeat-from: 2026-01-31\ngenerated: 2026-01-31\n---\n" + body,
+			today:   "2026-02-28",
+			changed: true,
+			added:   true,
+			want:    []string{"generated: 2026-02-28", "repeat-from: 2026-01-31", "## 2026-02-28\n\n- [ ] rent\n- [ ] power\n"},
+		},
+		{
+			name:    "back to the 31st after February",
+			content: "---\nrepeat-from: 2026-01-31\ngenerated: 2026-02-28\n---\n" + body,
+			today:   "2026-03-31",
+			changed: true,
+			added:   true,
+			want:    []string{"generated: 2026-03-31", "## 2026-03-31\n"},
+		},
+		{
+			name:    "leap year",
+			content: "---\nrepeat-from: 2028-01-31\ngenerated: 2028-01-31\n---\n" + body,
+			today:   "2028-02-29",
+			changed: true,
+			added:   true,
+			want:    []string{"generated: 2028-02-29", "## 2028-02-29\n"},
+		},
+		{
+			name:    "across the new year",
+			content: "---\nrepeat-from: 2026-12-31\ngenerated: 2026-12-31\n---\n" + body,
+			today:   "2027-01-31",
+			changed: true,
+			added:   true,
+			want:    []string{"generated: 2027-01-31", "## 2027-01-31\n"},
+		},
+		{
+			name:    "anchor added to notes without one",
+			content: "---\ngenerated: 2026-12-15\n---\n" + body,
+			today:   "2027-01-15",
+			changed: true,
+			added:   true,
+			want:    []string{"repeat-from: 2026-12-15", "generated: 2027-01-15"},
+		},
+		{
+			name:    "not recurring",
+			content: "# Notes\n\n- [ ] one\n",
+			today:   "2026-01-31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, added := repeatNote(tt.content, mustDate(tt.today))
+			if changed != tt.changed || added != tt.added {
+				t.Fatalf("changed, added = %v, %v, want %v, %v", changed, added, tt.changed, tt.added)
+			}
+			if !changed && got != tt.content {
+				t.Errorf("content changed without reporting it:\n%s", got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("missing %q in:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// defaultReflowWidth is the column paragraphs are reflowed to unless
+// $GOTODO_REFLOW_WIDTH says otherwise.
+const defaultReflowWidth = 80
+
+// listMarkerPattern matches the start of a list item, with its task box
+// if it has one, or a block quote, capturing everything before the text.
+var listMarkerPattern = regexp.MustCompile(`^(\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?|\s*>\s?)`)
+
+// listItemPattern matches a line that starts a new list item.
+var listItemPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s`)
+
+// reflowWidthFromEnv returns the column set by $GOTODO_REFLOW_WIDTH.
+func reflowWidthFromEnv() int {
+	width, err := strconv.Atoi(os.Getenv("GOTODO_REFLOW_WIDTH"))
+	if err != nil || width < 20 {
+		return defaultReflowWidth
+	}
+	return width
+}
+
+// paragraphBounds returns the first and last line of the paragraph
+// around row. Blank lines end a paragraph, and headings, fences and new
+// list items are paragraphs of their own.
+func paragraphBounds(lines []string, row int) (start, end int, ok bool) {
+	breaks := func(line string) bool {
+		return strings.TrimSpace(line) == "" || headingPattern.MatchString(line) || fencePattern.MatchString(line)
+	}
+	if breaks(lines[row]) {
+		return 0, 0, false
+	}
+	start = row
+	for start > 0 && !breaks(lines[start-1]) && !listItemPattern.MatchString(lines[start]) {
+		start--
+	}
+	end = row
+	for end+1 < len(lines) && !breaks(lines[end+1]) && !listItemPattern.MatchString(lines[end+1]) {
+		end++
+	}
+	return start, end, true
+}
+
+// reflowLines joins lines into one paragraph and breaks it again at word
+// boundaries to fit width. A list marker or quote on the first line is
+// kept, with the lines after it indented to match.
+func reflowLines(lines []string, width int) []string {
+	prefix := listMarkerPattern.FindString(lines[0])
+	if prefix == "" {
+		prefix = lines[0][:len(lines[0])-len(strings.TrimLeft(lines[0], " \t"))]
+	}
+	indent := strings.Repeat(" ", ansi.StringWidth(prefix))
+	if strings.HasPrefix(strings.TrimSpace(prefix), ">") {
+		indent = prefix
+	}
+
+	var words []string
+	for i, line := range lines {
+		if i == 0 {
+			line = line[len(prefix):]
+		} else {
+			line = strings.TrimPrefix(strings.TrimLeft(line, " \t"), strings.TrimSpace(indent))
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+
+	var out []string
+	current := prefix
+	empty := true
+	for _, word := range words {
+		if !empty && ansi.StringWidth(current)+1+ansi.StringWidth(word) > width {
+			out = append(out, current)
+			current, empty = indent, true
+		}
+		if !empty {
+			current += " "
+		}
+		current += word
+		empty = false
+	}
+	return append(out, current)
+}
+
+// reflowParagraph rewraps the paragraph the cursor is in to reflowWidth
+// columns, leaving the cursor at its end.
+func (m *model) reflowParagraph() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	start, end, ok := paragraphBounds(lines, min(m.editor.Line(), len(lines)-1))
+	if !ok {
+		m.editorStatus = "Nothing to reflow here"
+		return
+	}
+	reflowed := reflowLines(lines[start:end+1], m.reflowWidth)
+	lines = append(lines[:start], append(reflowed, lines[end+1:]...)...)
+	m.setEditorValue(strings.Join(lines, "\n"))
+	last := start + len(reflowed) - 1
+	m.setEditorCursor(last, len([]rune(lines[last])))
+	m.editorStatus = fmt.Sprintf("Reflowed to %d columns", m.reflowWidth)
+}
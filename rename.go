@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openRenameNote swaps the filename in the editor header for an input to
+// rename the note with.
+func (m *model) openRenameNote() tea.Cmd {
+	m.textInput.SetValue(m.currentFile)
+	m.textInput.CursorEnd()
+	m.textInput.Focus()
+	m.editor.Blur()
+	m.state = renameNoteView
+	return textinput.Blink
+}
+
+// closeRenameNote goes back to editing.
+func (m *model) closeRenameNote() tea.Cmd {
+	m.textInput.Blur()
+	m.textInput.SetValue("")
+	m.state = editorView
+	m.editor.Focus()
+	return tea.Batch(textarea.Blink, m.startAutosave())
+}
+
+// renameCurrentNote gives the note in the editor the name typed into the
+// header, moving the file too once it's been saved.
+func (m *model) renameCurrentNote() tea.Cmd {
+	oldName := m.currentFileName()
+	newName, err := renamedNote(oldName, m.textInput.Value())
+	cmd := m.closeRenameNote()
+	if err != nil {
+		m.editorStatus = "Rename failed: " + err.Error()
+		return cmd
+	}
+	if newName == oldName {
+		return cmd
+	}
+
+	var commitCmd tea.Cmd
+	if _, err := os.Stat(m.currentFilePath()); err == nil {
+		if err := m.renameNote(oldName, newName); errors.Is(err, fs.ErrExist) {
+			m.editorStatus = newName + " already exists"
+			return cmd
+		} else if err != nil {
+			m.editorStatus = "Rename failed: " + err.Error()
+			return cmd
+		}
+		commitCmd = m.gitCommit("rename "+oldName+" to "+newName, oldName, newName)
+	} else if existing, ok := m.existingNote(strings.TrimSuffix(newName, encryptedExt)); ok {
+		// Not saved yet, so only the name changes, but it mustn't be
+		// saved over another note
+		m.editorStatus = existing + " already exists"
+		return cmd
+	}
+
+	m.currentFile = noteName(newName)
+	m.currentExt = baseExt(newName)
+	m.dropRecent(oldName)
+	m.pushRecent(newName)
+	m.editorStatus = "Renamed to " + newName
+	return tea.Batch(cmd, commitCmd)
+}
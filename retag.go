@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tagNamePattern is what may follow the # of a tag, as tagPattern reads it.
+var tagNamePattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// tagRenamedMsg reports how renaming a tag across the notes went.
+type tagRenamedMsg struct {
+	from, to string
+	changed  []string
+	failed   int
+	err      error
+}
+
+// parseTagName reads a tag typed with or without its #.
+func parseTagName(s string) (string, error) {
+	tag := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if !tagNamePattern.MatchString(tag) {
+		return "", fmt.Errorf("%q isn't a tag", s)
+	}
+	return tag, nil
+}
+
+// replaceTag renames every #from in content to #to, matching from without
+// regard to case as tag filters do, and returns how many were replaced.
+func replaceTag(content, from, to string) (string, int) {
+	count := 0
+	replaced := tagPattern.ReplaceAllStringFunc(content, func(match string) string {
+		lead, tag, _ := strings.Cut(match, "#")
+		if !strings.EqualFold(tag, from) {
+			return match
+		}
+		count++
+		return lead + "#" + to
+	})
+	return replaced, count
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file next
+// to it and renaming that over the original, so a crash part way through
+// leaves either the old note or the new one.
+func writeFileAtomic(path string, data []byte) error {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".retag-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// renameTagInNotes renames the tag from to to in every note under dir,
+// skipping hidden folders and the archive as the todo list does. It
+// carries on past notes it can't rewrite, returning the ones it changed,
+// how many failed and the first failure. Encrypted notes are only touched
+// when cipher can open them.
+func renameTagInNotes(dir string, cipher *noteCipher, from, to string) (changed []string, failed int, firstErr error) {
+	archiveDir := filepath.Join(dir, archiveDirName)
+	err := filepath.WalkDir(dir, func(path string, file fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if file.IsDir() {
+			if path != dir && (strings.HasPrefix(file.Name(), ".") || path == archiveDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isNoteFile(file.Name()) {
+			return nil
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil
+		}
+		filename := filepath.ToSlash(relPath)
+
+		content, err := readNoteFile(dir, cipher, filename)
+		if err != nil {
+			if !errors.Is(err, errNoPassphrase) {
+				failed++
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", filename, err)
+				}
+			}
+			return nil
+		}
+		updated, count := replaceTag(string(content), from, to)
+		if count == 0 {
+			return nil
+		}
+
+		data := []byte(updated)
+		if isEncrypted(filename) {
+			data, err = cipher.encrypt(data)
+		}
+		if err == nil {
+			err = writeFileAtomic(path, data)
+		}
+		logOp("retag", filename, err)
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", filename, err)
+			}
+			return nil
+		}
+		changed = append(changed, filename)
+		return nil
+	})
+	if err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return changed, failed, firstErr
+}
+
+// openRenameTag asks for the tag to rename, then what to call it.
+func (m *model) openRenameTag() tea.Cmd {
+	m.retagFrom = ""
+	m.retagStatus = ""
+	m.textInput.SetValue("")
+	m.textInput.Focus()
+	m.state = renameTagView
+	return textinput.Blink
+}
+
+// closeRenameTag goes back to the main menu.
+func (m *model) closeRenameTag() {
+	m.textInput.Blur()
+	m.textInput.SetValue("")
+	m.retagFrom = ""
+	m.retagStatus = ""
+	m.state = listView
+}
+
+// submitRenameTag takes the tag typed so far: the first one entered is the
+// tag to rename, the second its new name, which starts the rename.
+func (m *model) submitRenameTag() tea.Cmd {
+	tag, err := parseTagName(m.textInput.Value())
+	if err != nil {
+		m.retagStatus = err.Error()
+		return nil
+	}
+	m.retagStatus = ""
+	if m.retagFrom == "" {
+		m.retagFrom = tag
+		m.textInput.SetValue(tag)
+		m.textInput.CursorEnd()
+		return nil
+	}
+	if tag == m.retagFrom {
+		m.closeRenameTag()
+		return nil
+	}
+
+	from := m.retagFrom
+	m.closeRenameTag()
+	dir, cipher := m.todoDir, m.cipher
+	return func() tea.Msg {
+		changed, failed, err := renameTagInNotes(dir, cipher, from, tag)
+		return tagRenamedMsg{from: from, to: tag, changed: changed, failed: failed, err: err}
+	}
+}
+
+// tagRenamed brings the index up to date with the rewritten notes, commits
+// them and reports on the main menu.
+func (m *model) tagRenamed(msg tagRenamedMsg) tea.Cmd {
+	for _, filename := range msg.changed {
+		m.index.refresh(filename)
+	}
+	m.saveIndex()
+
+	status := fmt.Sprintf("Renamed #%s to #%s in %d file(s)", msg.from, msg.to, len(msg.changed))
+	level := statusNotice
+	switch {
+	case msg.err != nil && msg.failed == 0:
+		// The notes couldn't be listed at all
+		status = fmt.Sprintf("Renaming #%s failed: %v", msg.from, msg.err)
+		level = statusError
+	case msg.err != nil:
+		status += fmt.Sprintf(", %d failed (%v)", msg.failed, msg.err)
+		level = statusError
+	}
+
+	var commitCmd tea.Cmd
+	if len(msg.changed) > 0 {
+		commitCmd = m.gitCommit(fmt.Sprintf("rename tag #%s to #%s", msg.from, msg.to), msg.changed...)
+	}
+	return tea.Batch(m.listStatus(&m.mainList, level, status), commitCmd)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// scratchName is the note the scratchpad key opens, so there's always
+// somewhere to jot things down without naming a file first.
+const scratchName = "scratch"
+
+// openScratch opens the scratchpad in the editor, starting it empty when
+// it hasn't been saved yet. An encrypted scratchpad is preferred while a
+// passphrase is set.
+func (m *model) openScratch() tea.Cmd {
+	names := []string{scratchName + ".md"}
+	if m.cipher != nil {
+		names = append([]string{scratchName + ".md" + encryptedExt}, names...)
+	}
+	for _, name := range names {
+		cmd, err := m.openInEditor(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return m.listStatus(&m.mainList, statusError, "Open failed: "+err.Error())
+		}
+		return cmd
+	}
+
+	m.currentFile = scratchName
+	m.currentExt = ".md"
+	return m.newTodo("")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+var searchMatchStyle = lipgloss.NewStyle().
+	Background(lipgloss.Color("220")).
+	Foreground(lipgloss.Color("0"))
+
+// searchMatch is where an occurrence of the search starts in the buffer,
+// as a row and a rune column.
+type searchMatch struct {
+	row, col int
+}
+
+// foldSearch reports whether query should match regardless of case,
+// which is whenever it's all lower case.
+func foldSearch(query string) bool {
+	return strings.IndexFunc(query, unicode.IsUpper) < 0
+}
+
+// findMatches returns every occurrence of query in content, in order.
+func findMatches(content, query string) []searchMatch {
+	if query == "" {
+		return nil
+	}
+	fold := foldSearch(query)
+	if fold {
+		query = strings.ToLower(query)
+	}
+	needle := []rune(query)
+
+	var matches []searchMatch
+	for row, line := range strings.Split(content, "\n") {
+		if fold {
+			line = strings.ToLower(line)
+		}
+		runes := []rune(line)
+		for col := 0; col+len(needle) <= len(runes); col++ {
+			if string(runes[col:col+len(needle)]) == query {
+				matches = append(matches, searchMatch{row: row, col: col})
+				col += len(needle) - 1
+			}
+		}
+	}
+	return matches
+}
+
+// openSearch starts a search of the editor buffer from the cursor.
+func (m *model) openSearch() tea.Cmd {
+	row, col := m.editorCursor()
+	m.searchOrigin = searchMatch{row: row, col: col}
+	m.searchMatches = nil
+	m.searchIndex = 0
+	m.searchInput.SetValue("")
+	m.searchInput.Focus()
+	m.state = searchView
+	return textinput.Blink
+}
+
+// updateSearch finds the query again as it's typed, moving the cursor to
+// the first match at or after where the search started.
+func (m *model) updateSearch() {
+	m.searchMatches = findMatches(m.editor.Value(), m.searchInput.Value())
+	if len(m.searchMatches) == 0 {
+		m.setEditorCursor(m.searchOrigin.row, m.searchOrigin.col)
+		return
+	}
+	m.searchIndex = 0
+	for i, match := range m.searchMatches {
+		if match.row > m.searchOrigin.row || (match.row == m.searchOrigin.row && match.col >= m.searchOrigin.col) {
+			m.searchIndex = i
+			break
+		}
+	}
+	m.showMatch()
+}
+
+// stepSearch moves to the next match, or the previous one when delta is
+// negative, wrapping around at either end.
+func (m *model) stepSearch(delta int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	n := len(m.searchMatches)
+	m.searchIndex = ((m.searchIndex+delta)%n + n) % n
+	m.showMatch()
+}
+
+func (m *model) showMatch() {
+	match := m.searchMatches[m.searchIndex]
+	m.setEditorCursor(match.row, match.col)
+}
+
+// closeSearch leaves search and returns to editing, either where the
+// cursor has got to or back where the search started.
+func (m *model) closeSearch(restore bool) tea.Cmd {
+	if restore {
+		m.setEditorCursor(m.searchOrigin.row, m.searchOrigin.col)
+	}
+	m.searchInput.Blur()
+	m.searchInput.SetValue("")
+	m.searchMatches = nil
+	m.state = editorView
+	m.editor.Focus()
+	return tea.Batch(textarea.Blink, m.startAutosave())
+}
+
+// searchStatus is the "x of y matches" footer shown while searching.
+func (m model) searchStatus() string {
+	if m.searchInput.Value() == "" {
+		return "type to search"
+	}
+	if len(m.searchMatches) == 0 {
+		return "no matches"
+	}
+	return fmt.Sprintf("%d of %d matches", m.searchIndex+1, len(m.searchMatches))
+}
+
+// searchHelpText is the editor footer while searching.
+func (m model) searchHelpText() string {
+	if m.searchInput.Focused() {
+		return m.searchInput.View() + "  " + m.searchStatus() + " | enter: step through matches | esc: cancel"
+	}
+	return m.searchStatus() + " | n/N: next/prev | /: change search | enter/esc: back to editing"
+}
+
+// highlightMatches marks occurrences of query in the rendered editor,
+// skipping the gutter of border and line numbers. Matches split by soft wrapping
+// aren't marked.
+func highlightMatches(view, query string, gutter int) string {
+	if query == "" {
+		return view
+	}
+	fold := foldSearch(query)
+	if fold {
+		query = strings.ToLower(query)
+	}
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		if fold {
+			plain = strings.ToLower(plain)
+		}
+		plain = ansi.Cut(plain, gutter, ansi.StringWidth(plain))
+
+		pos := 0 // cells of plain already searched, after the gutter
+		for {
+			idx := strings.Index(plain, query)
+			if idx < 0 {
+				break
+			}
+			start := gutter + pos + ansi.StringWidth(plain[:idx])
+			end := start + ansi.StringWidth(query)
+			line = ansi.Cut(line, 0, start) +
+				searchMatchStyle.Render(ansi.Strip(ansi.Cut(line, start, end))) +
+				ansi.Cut(line, end, ansi.StringWidth(line))
+			plain = plain[idx+len(query):]
+			pos = end - gutter
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
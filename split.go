@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// splitMinWidth is the narrowest terminal the editor is split with a
+// live preview in; anything narrower falls back to the ctrl+p toggle.
+const splitMinWidth = 100
+
+// splitRefreshDelay is how long typing has to pause before the split
+// preview is rendered again.
+const splitRefreshDelay = 300 * time.Millisecond
+
+type splitRefreshMsg struct {
+	id int
+}
+
+// splitActive reports whether the editor is shown beside a live preview.
+func (m model) splitActive() bool {
+	return m.splitView && !m.zen && m.width >= splitMinWidth
+}
+
+// editorPaneWidth is the width the editor gets, half the screen when
+// split and no wider than zenTextWidth in zen mode.
+func (m model) editorPaneWidth() int {
+	h, _ := docStyle.GetFrameSize()
+	if m.zen {
+		return min(m.width-h, zenTextWidth)
+	}
+	if m.splitActive() {
+		return (m.width - h) / 2
+	}
+	return m.width - h
+}
+
+// initSplitPreview sizes the preview viewport to the right-hand pane and
+// renders the note into it.
+func (m *model) initSplitPreview() {
+	h, _ := docStyle.GetFrameSize()
+	width := m.width - h - m.editorPaneWidth() - blurredBorderStyle.GetHorizontalFrameSize()
+	m.viewport = viewport.New(width, m.editor.Height())
+	m.refreshPreview()
+	m.splitSeen = m.editor.Value()
+}
+
+// scheduleSplitRefresh re-renders the split preview once typing pauses;
+// each call supersedes the ones before it.
+func (m *model) scheduleSplitRefresh() tea.Cmd {
+	m.splitRefreshID++
+	id := m.splitRefreshID
+	return tea.Tick(splitRefreshDelay, func(time.Time) tea.Msg {
+		return splitRefreshMsg{id: id}
+	})
+}
+
+// splitPreviewView renders the right-hand pane, scrolled to roughly the
+// part of the note the cursor is in.
+func (m model) splitPreviewView() string {
+	vp := m.viewport
+	if lines := m.editor.LineCount(); lines > 1 {
+		row := m.editor.Line() * vp.TotalLineCount() / lines
+		vp.SetYOffset(row - vp.Height/2)
+	}
+	return blurredBorderStyle.Render(vp.View())
+}
+
+// splitEditorView joins the rendered editor and its live preview side by
+// side.
+func (m model) splitEditorView(editor string) string {
+	return lipgloss.JoinHorizontal(lipgloss.Top, editor, m.splitPreviewView())
+}
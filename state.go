@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// stateFileName is the file in the todo directory that remembers the
+// previous session.
+const stateFileName = ".gotodo-state.json"
+
+type appState struct {
+	SortMode     sortMode             `json:"sortMode"`
+	TodoListOpen bool                 `json:"todoListOpen"`
+	LastSelected string               `json:"lastSelected,omitempty"`
+	Pinned       []string             `json:"pinned,omitempty"`
+	Recent       []string             `json:"recent,omitempty"`
+	EditorNoWrap bool                 `json:"editorNoWrap,omitempty"`
+	Labels       map[string]string    `json:"labels,omitempty"`
+	MenuUses     map[string]int       `json:"menuUses,omitempty"`
+	Cursors      map[string]cursorPos `json:"cursors,omitempty"`
+	Workspace    string               `json:"workspace,omitempty"`
+}
+
+// loadState reads the saved session state from dir. A missing or
+// malformed file yields the defaults.
+func loadState(dir string) appState {
+	var state appState
+
+	data, err := os.ReadFile(filepath.Join(dir, stateFileName))
+	if err != nil {
+		return appState{}
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return appState{}
+	}
+	if state.SortMode < sortByName || state.SortMode > sortByPriority {
+		state.SortMode = sortByName
+	}
+	for filename, label := range state.Labels {
+		if _, ok := labelColors[label]; !ok {
+			delete(state.Labels, filename)
+		}
+	}
+	return state
+}
+
+// saveState writes the current session state to the todo directory.
+func (m model) saveState() error {
+	switch m.state {
+	case editorView, searchView, renameNoteView, diffView:
+		m.rememberCursor()
+	}
+	state := appState{
+		SortMode:     m.sortMode,
+		LastSelected: m.lastSelected,
+		Recent:       m.recent,
+		EditorNoWrap: m.editorNoWrap,
+		Labels:       m.existingLabels(),
+		MenuUses:     m.menuUses,
+		Cursors:      m.existingCursors(),
+	}
+	if m.todoDir == m.workspaces[0].dir {
+		state.Workspace = m.workspace
+	}
+	for filename := range m.pinned {
+		state.Pinned = append(state.Pinned, filename)
+	}
+	sort.Strings(state.Pinned)
+
+	switch m.state {
+	case todoListView, renameTodoView:
+		state.TodoListOpen = true
+	}
+	if selected, ok := m.todoList.SelectedItem().(todoItem); ok {
+		state.LastSelected = selected.filename
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.todoDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(m.todoDir, stateFileName), data, 0644)
+}
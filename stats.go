@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	statLabelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("241")).
+			Width(22)
+
+	statValueStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("212"))
+
+	statsBoxStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("99")).
+			Padding(1, 2)
+)
+
+type todoStats struct {
+	notes      int
+	withTasks  int
+	open       int
+	completed  int
+	lastEdited todoItem
+}
+
+// computeStats aggregates the checkbox counts gathered by loadTodoFiles.
+func computeStats(items []list.Item) todoStats {
+	var s todoStats
+	for _, it := range items {
+		todo := it.(todoItem)
+		s.notes++
+		if todo.total > 0 {
+			s.withTasks++
+		}
+		s.completed += todo.done
+		s.open += todo.total - todo.done
+		if todo.modified.After(s.lastEdited.modified) {
+			s.lastEdited = todo
+		}
+	}
+	return s
+}
+
+// statsView renders the dashboard for the stats computed on entry.
+func (m model) statsView() string {
+	s := m.stats
+
+	percent := 0
+	if total := s.open + s.completed; total > 0 {
+		percent = s.completed * 100 / total
+	}
+	lastEdited := "none"
+	if s.lastEdited.filename != "" {
+		lastEdited = fmt.Sprintf("%s (%s)", s.lastEdited.filename, s.lastEdited.modified.Format("Jan 02, 2006 3:04 PM"))
+	}
+
+	rows := []struct{ label, value string }{
+		{"Notes", fmt.Sprint(s.notes)},
+		{"Notes with tasks", fmt.Sprint(s.withTasks)},
+		{"Open tasks", fmt.Sprint(s.open)},
+		{"Completed tasks", fmt.Sprintf("%d (%d%%)", s.completed, percent)},
+		{"Last edited", lastEdited},
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(statLabelStyle.Render(row.label) + statValueStyle.Render(row.value))
+	}
+
+	appTitle := appTitleStyle.Render("Statistics")
+	help := helpStyle.Render("esc: back")
+	return appTitle + "\n" + statsBoxStyle.Render(b.String()) + "\n" + help
+}
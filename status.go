@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// statusKind says how long a list's status message stays up and how it
+// looks.
+type statusKind int
+
+const (
+	// statusInfo acknowledges something the user can see happened
+	statusInfo statusKind = iota
+	// statusNotice reports something worth reading, like where a file
+	// went or what was deleted
+	statusNotice
+	// statusError explains why something didn't work
+	statusError
+)
+
+var statusKindNames = map[string]statusKind{
+	"info":   statusInfo,
+	"notice": statusNotice,
+	"error":  statusError,
+}
+
+// statusLifetimes holds how long each kind of status message lasts.
+type statusLifetimes [statusError + 1]time.Duration
+
+// statusLifetimesFromEnv reads $GOTODO_STATUS_TIMEOUT, either one
+// duration for every message or a list like "info=2s,error=10s" for
+// some kinds. Whatever isn't set, or can't be parsed, keeps its default.
+func statusLifetimesFromEnv() statusLifetimes {
+	lifetimes := statusLifetimes{
+		statusInfo:   time.Second,
+		statusNotice: 4 * time.Second,
+		statusError:  6 * time.Second,
+	}
+	setting := os.Getenv("GOTODO_STATUS_TIMEOUT")
+	if d, err := time.ParseDuration(strings.TrimSpace(setting)); err == nil && d > 0 {
+		for kind := range lifetimes {
+			lifetimes[kind] = d
+		}
+		return lifetimes
+	}
+	for _, field := range strings.Split(setting, ",") {
+		name, value, ok := strings.Cut(field, "=")
+		kind, known := statusKindNames[strings.TrimSpace(name)]
+		if !ok || !known {
+			continue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(value)); err == nil && d > 0 {
+			lifetimes[kind] = d
+		}
+	}
+	return lifetimes
+}
+
+// listStatus shows text in l's status bar, colored and timed for kind.
+func (m model) listStatus(l *list.Model, kind statusKind, text string) tea.Cmd {
+	styled := statusMessageStyle(text)
+	if kind == statusError {
+		styled = errorMessageStyle.Render(text)
+	}
+	lifetime := l.StatusMessageLifetime
+	l.StatusMessageLifetime = m.statusLifetimes[kind]
+	cmd := l.NewStatusMessage(styled)
+	l.StatusMessageLifetime = lifetime
+	return cmd
+}
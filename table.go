@@ -0,0 +1,168 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// tableSeparatorPattern matches one cell of a table's separator row,
+// with its optional alignment colons.
+var tableSeparatorPattern = regexp.MustCompile(`^:?-+:?$`)
+
+// tableBounds returns the first and last line of the run of lines with a
+// pipe in them around row.
+func tableBounds(lines []string, row int) (start, end int, ok bool) {
+	isRow := func(line string) bool { return strings.Contains(line, "|") }
+	if !isRow(lines[row]) {
+		return 0, 0, false
+	}
+	start, end = row, row
+	for start > 0 && isRow(lines[start-1]) {
+		start--
+	}
+	for end+1 < len(lines) && isRow(lines[end+1]) {
+		end++
+	}
+	return start, end, true
+}
+
+// tableCells splits a table row into its trimmed cells, leaving escaped
+// pipes inside a cell alone.
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+	var cells []string
+	var cell strings.Builder
+	escaped := false
+	for _, r := range line {
+		if r == '|' && !escaped {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		escaped = r == '\\' && !escaped
+		cell.WriteRune(r)
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// formatTable pads the cells of a GitHub-style table so every column
+// lines up, keeping the alignment colons of the separator row. It reports
+// false when the second line isn't a separator row.
+func formatTable(lines []string) ([]string, bool) {
+	if len(lines) < 2 {
+		return nil, false
+	}
+	rows := make([][]string, len(lines))
+	for i, line := range lines {
+		rows[i] = tableCells(line)
+	}
+	for _, cell := range rows[1] {
+		if !tableSeparatorPattern.MatchString(cell) {
+			return nil, false
+		}
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	aligns := make([]string, columns)
+	for i := range widths {
+		widths[i] = 3
+		if i < len(rows[1]) {
+			sep := rows[1][i]
+			switch {
+			case strings.HasPrefix(sep, ":") && strings.HasSuffix(sep, ":"):
+				aligns[i] = "center"
+			case strings.HasSuffix(sep, ":"):
+				aligns[i] = "right"
+			case strings.HasPrefix(sep, ":"):
+				aligns[i] = "left"
+			}
+		}
+	}
+	for r, row := range rows {
+		if r == 1 {
+			continue
+		}
+		for i, cell := range row {
+			widths[i] = max(widths[i], ansi.StringWidth(cell))
+		}
+	}
+
+	indent := lines[0][:len(lines[0])-len(strings.TrimLeft(lines[0], " \t"))]
+	out := make([]string, len(rows))
+	for r, row := range rows {
+		var b strings.Builder
+		b.WriteString(indent + "|")
+		for i, width := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if r == 1 {
+				cell = separatorCell(aligns[i], width)
+			} else {
+				cell = padCell(cell, aligns[i], width)
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		out[r] = b.String()
+	}
+	return out, true
+}
+
+// separatorCell draws a separator cell width wide for the given
+// alignment.
+func separatorCell(align string, width int) string {
+	switch align {
+	case "center":
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case "right":
+		return strings.Repeat("-", width-1) + ":"
+	case "left":
+		return ":" + strings.Repeat("-", width-1)
+	}
+	return strings.Repeat("-", width)
+}
+
+// padCell pads cell out to width, on the side its alignment calls for.
+func padCell(cell, align string, width int) string {
+	gap := width - ansi.StringWidth(cell)
+	switch align {
+	case "center":
+		return strings.Repeat(" ", gap/2) + cell + strings.Repeat(" ", gap-gap/2)
+	case "right":
+		return strings.Repeat(" ", gap) + cell
+	}
+	return cell + strings.Repeat(" ", gap)
+}
+
+// formatTableAtCursor lines up the columns of the table the cursor is in.
+func (m *model) formatTableAtCursor() {
+	lines := strings.Split(m.editor.Value(), "\n")
+	start, end, ok := tableBounds(lines, min(m.editor.Line(), len(lines)-1))
+	if !ok {
+		m.editorStatus = "No table here"
+		return
+	}
+	formatted, ok := formatTable(lines[start : end+1])
+	if !ok {
+		m.editorStatus = "No table here"
+		return
+	}
+	if strings.Join(formatted, "\n") == strings.Join(lines[start:end+1], "\n") {
+		m.editorStatus = "Table already lined up"
+		return
+	}
+	lines = append(lines[:start], append(formatted, lines[end+1:]...)...)
+	m.setEditorValue(strings.Join(lines, "\n"))
+	m.editorStatus = "Formatted table"
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// templatesDirName is the folder in the todo directory holding note
+// templates. Being hidden keeps templates out of the todo list.
+const templatesDirName = ".templates"
+
+// blankTemplate is the picker entry for starting without a template.
+const blankTemplate = "Blank"
+
+func (m *model) templatesDir() string {
+	return filepath.Join(m.todoDir, templatesDirName)
+}
+
+// loadTemplates lists the markdown files in the templates folder.
+func (m *model) loadTemplates() []list.Item {
+	entries, err := os.ReadDir(m.templatesDir())
+	if err != nil {
+		return nil
+	}
+
+	var items []list.Item
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		items = append(items, item{title: strings.TrimSuffix(entry.Name(), ".md"), desc: "template"})
+	}
+	return items
+}
+
+// openTemplatePicker offers templates for the new note, blank first.
+func (m *model) openTemplatePicker(templates []list.Item) {
+	items := append([]list.Item{item{title: blankTemplate, desc: "start with an empty note"}}, templates...)
+	m.templateList = list.New(items, list.NewDefaultDelegate(), 0, 0)
+	m.templateList.Title = "Template for " + m.currentFile
+	m.templateList.Styles.Title = todoTitleStyle
+
+	h, v := docStyle.GetFrameSize()
+	m.templateList.SetSize(m.width-h, m.height-v)
+
+	m.state = templateView
+}
+
+// applyTemplate starts the new note from the named template.
+func (m *model) applyTemplate(name string) tea.Cmd {
+	if name == blankTemplate {
+		return m.newTodo("")
+	}
+	content, err := os.ReadFile(filepath.Join(m.templatesDir(), name+".md"))
+	if err != nil {
+		return m.listStatus(&m.templateList, statusError, "Template failed: "+err.Error())
+	}
+	return m.newTodo(string(content))
+}
+
+// newTodo opens the editor on the note named by m.currentFile, starting
+// from content. Prefilled content counts as unsaved. New notes are
+// encrypted whenever there's a passphrase.
+func (m *model) newTodo(content string) tea.Cmd {
+	m.encrypted = m.cipher != nil
+	m.loadEditor(content)
+	m.dirty = content != ""
+	m.pushRecent(m.currentFileName())
+	m.state = editorView
+	m.editor.Focus()
+	return tea.Batch(textarea.Blink, m.startAutosave())
+}
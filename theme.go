@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// themeConfigPath returns where the theme is read from, normally
+// ~/.config/gotodo/theme.json.
+func themeConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotodo", "theme.json"), nil
+}
+
+// themeColors is the foreground and background of a styled piece of
+// text. Either may be left out to keep the default.
+type themeColors struct {
+	Foreground string `json:"foreground"`
+	Background string `json:"background"`
+}
+
+// themeConfig is the theme file. Margins and padding are in cells, given
+// as one, two or four values like CSS; colors are ANSI numbers or hex.
+//
+//	{
+//	  "margin": [1, 2],
+//	  "appTitle": {"foreground": "255", "background": "99"},
+//	  "listTitle": {"foreground": "#FFFDF5", "background": "#25A065"},
+//	  "status": "#04B575",
+//	  "error": "#FF5F87"
+//	}
+type themeConfig struct {
+	Margin     []int       `json:"margin"`
+	Padding    []int       `json:"padding"`
+	AppTitle   themeColors `json:"appTitle"`
+	ListTitle  themeColors `json:"listTitle"`
+	CursorLine themeColors `json:"cursorLine"`
+	Cursor     string      `json:"cursor"`
+	Border     string      `json:"border"`
+	Help       string      `json:"help"`
+	Status     string      `json:"status"`
+	Error      string      `json:"error"`
+}
+
+// themeColorPattern matches the colors lipgloss understands.
+var themeColorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+// validate reports a setting in t that can't be used, if there is one.
+func (t themeConfig) validate() error {
+	for name, sides := range map[string][]int{"margin": t.Margin, "padding": t.Padding} {
+		if sides == nil {
+			continue
+		}
+		if len(sides) != 1 && len(sides) != 2 && len(sides) != 4 {
+			return fmt.Errorf("%s wants 1, 2 or 4 values, got %d", name, len(sides))
+		}
+		for _, n := range sides {
+			if n < 0 {
+				return fmt.Errorf("%s can't be negative", name)
+			}
+		}
+	}
+	colors := map[string]string{
+		"appTitle.foreground":   t.AppTitle.Foreground,
+		"appTitle.background":   t.AppTitle.Background,
+		"listTitle.foreground":  t.ListTitle.Foreground,
+		"listTitle.background":  t.ListTitle.Background,
+		"cursorLine.foreground": t.CursorLine.Foreground,
+		"cursorLine.background": t.CursorLine.Background,
+		"cursor":                t.Cursor,
+		"border":                t.Border,
+		"help":                  t.Help,
+		"status":                t.Status,
+		"error":                 t.Error,
+	}
+	for name, color := range colors {
+		if color != "" && !themeColorPattern.MatchString(color) {
+			return fmt.Errorf("%s: %q isn't an ANSI color number or hex color", name, color)
+		}
+	}
+	return nil
+}
+
+// loadTheme applies the theme in path over the default styles. A missing
+// file is fine; a broken one leaves the defaults alone.
+func loadTheme(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var theme themeConfig
+	if err := json.Unmarshal(data, &theme); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := theme.validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	applyTheme(theme)
+	return nil
+}
+
+// withColors sets whichever of c's colors are given on style.
+func withColors(style lipgloss.Style, c themeColors) lipgloss.Style {
+	if c.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(c.Foreground))
+	}
+	if c.Background != "" {
+		style = style.Background(lipgloss.Color(c.Background))
+	}
+	return style
+}
+
+// applyTheme rebuilds the shared styles from theme. It has to run before
+// the model is set up, which copies some of them.
+func applyTheme(theme themeConfig) {
+	if theme.Margin != nil {
+		docStyle = docStyle.Margin(theme.Margin...)
+	}
+	if theme.Padding != nil {
+		docStyle = docStyle.Padding(theme.Padding...)
+	}
+	appTitleStyle = withColors(appTitleStyle, theme.AppTitle)
+	todoTitleStyle = withColors(todoTitleStyle, theme.ListTitle)
+	cursorLineStyle = withColors(cursorLineStyle, theme.CursorLine)
+	if theme.Cursor != "" {
+		cursorStyle = cursorStyle.Foreground(lipgloss.Color(theme.Cursor))
+	}
+	if theme.Border != "" {
+		focusedBorderStyle = focusedBorderStyle.BorderForeground(lipgloss.Color(theme.Border))
+		frontmatterBoxStyle = frontmatterBoxStyle.BorderForeground(lipgloss.Color(theme.Border))
+	}
+	if theme.Help != "" {
+		helpStyle = helpStyle.Foreground(lipgloss.Color(theme.Help))
+	}
+	if theme.Status != "" {
+		statusMessageStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Status)).Render
+	}
+	if theme.Error != "" {
+		errorMessageStyle = errorMessageStyle.Foreground(lipgloss.Color(theme.Error))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// tocMaxWidth caps the width of the preview's table of contents panel.
+const tocMaxWidth = 32
+
+var (
+	tocStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			BorderForeground(lipgloss.Color("238")).
+			PaddingLeft(1)
+
+	tocSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("230")).
+				Background(lipgloss.Color("57"))
+)
+
+// headingPattern matches an ATX heading, capturing its level and text
+// without any closing #s.
+var headingPattern = regexp.MustCompile(`^ {0,3}(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+
+// fencePattern matches the line that opens or closes a fenced code block.
+var fencePattern = regexp.MustCompile("^ {0,3}(```|~~~)")
+
+// inlineMarkupReplacer drops the emphasis and code markers glamour
+// doesn't print, so headings read the same in the panel and can be found
+// in the rendered output.
+var inlineMarkupReplacer = strings.NewReplacer("**", "", "__", "", "*", "", "`", "")
+
+// tocEntry is a heading in the preview's table of contents. offset is the
+// line of the rendered preview it was found on.
+type tocEntry struct {
+	level  int
+	text   string
+	offset int
+}
+
+// parseHeadings returns the headings in content, skipping anything that
+// looks like one inside a fenced code block.
+func parseHeadings(content string) []tocEntry {
+	var entries []tocEntry
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		match := headingPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if text := strings.TrimSpace(inlineMarkupReplacer.Replace(match[2])); text != "" {
+			entries = append(entries, tocEntry{level: len(match[1]), text: text})
+		}
+	}
+	return entries
+}
+
+// locateHeadings finds each heading's line in rendered, searching in
+// heading order from where the previous one was found. A heading that
+// can't be found (say, one wrapped mid-word) is placed at the one before
+// it, which is close enough to jump to.
+func locateHeadings(entries []tocEntry, rendered string) {
+	lines := strings.Split(ansi.Strip(rendered), "\n")
+	next := 0
+	for i := range entries {
+		text := entries[i].text
+		if runes := []rune(text); len(runes) > 20 {
+			text = string(runes[:20])
+		}
+
+		entries[i].offset = max(next-1, 0)
+		for j := next; j < len(lines); j++ {
+			if strings.Contains(lines[j], text) {
+				entries[i].offset = j
+				next = j + 1
+				break
+			}
+		}
+	}
+}
+
+// tocWidth is the width the table of contents takes from the preview.
+func (m model) tocWidth() int {
+	if !m.tocVisible {
+		return 0
+	}
+	return min(tocMaxWidth, m.width/3)
+}
+
+// selectHeadingAt selects the last heading at or above offset.
+func (m *model) selectHeadingAt(offset int) {
+	m.tocSelected = 0
+	for i, entry := range m.toc {
+		if entry.offset <= offset {
+			m.tocSelected = i
+		}
+	}
+}
+
+// jumpToHeading selects heading i and scrolls the preview to it.
+func (m *model) jumpToHeading(i int) {
+	if len(m.toc) == 0 {
+		return
+	}
+	m.tocSelected = min(max(i, 0), len(m.toc)-1)
+	m.viewport.SetYOffset(m.toc[m.tocSelected].offset)
+}
+
+// tocView renders the table of contents beside the preview viewport.
+func (m model) tocView() string {
+	width := m.tocWidth() - tocStyle.GetHorizontalFrameSize()
+	var lines []string
+	if len(m.toc) == 0 {
+		lines = append(lines, lineNumberStyle.Render("No headings"))
+	}
+	for i, entry := range m.toc {
+		line := ansi.Truncate(strings.Repeat("  ", entry.level-1)+entry.text, width, "…")
+		if i == m.tocSelected {
+			line = tocSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	// Keep the selection on screen in long outlines
+	height := m.viewport.Height
+	if start := m.tocSelected - height + 1; start > 0 {
+		lines = lines[start:]
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return tocStyle.Width(width + 1).Height(height).Render(strings.Join(lines, "\n"))
+}
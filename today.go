@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// todayTag marks a task, or a whole note, as something to do today.
+const todayTag = "today"
+
+// todayTask is an open task shown in the today view.
+type todayTask struct {
+	filename string
+	row      int
+	text     string
+}
+
+func (t todayTask) Title() string { return t.text }
+
+func (t todayTask) Description() string {
+	return fmt.Sprintf("%s:%d", t.filename, t.row+1)
+}
+
+func (t todayTask) FilterValue() string { return t.text }
+
+// sameDay reports whether a and b fall on the same calendar day.
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// openTasks returns the unchecked tasks in content. When all is false
+// only tasks tagged #today are kept.
+func openTasks(filename, content string, all bool) []list.Item {
+	var tasks []list.Item
+	for row, line := range strings.Split(content, "\n") {
+		match := checkboxPattern.FindStringSubmatch(line)
+		if match == nil || match[2] != " " {
+			continue
+		}
+		if !all && !hasTag(extractTags(line), todayTag) {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimPrefix(match[3], "]"))
+		tasks = append(tasks, todayTask{filename: filename, row: row, text: text})
+	}
+	return tasks
+}
+
+// noteTagged reports whether tag appears in content outside its tasks,
+// which tags the note as a whole.
+func noteTagged(content, tag string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if !checkboxPattern.MatchString(line) && hasTag(extractTags(line), tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// openToday switches to the today view and starts gathering today's
+// tasks for it.
+func (m *model) openToday() tea.Cmd {
+	m.todayList = list.New(nil, list.NewDefaultDelegate(), 0, 0)
+	m.todayList.Title = "Today · " + time.Now().Format("Mon Jan 02")
+	m.todayList.Styles.Title = todoTitleStyle
+	m.todayList.SetStatusBarItemName("task", "tasks")
+
+	m.todayLoading = true
+	m.state = todayView
+	return m.loadToday()
+}
+
+// openTodayTask opens the file task comes from with the cursor on it.
+func (m *model) openTodayTask(task todayTask) tea.Cmd {
+	cmd, err := m.openInEditor(task.filename)
+	if err != nil {
+		return m.listStatus(&m.todayList, statusError, "Open failed: "+err.Error())
+	}
+	m.setEditorCursor(task.row, 0)
+	return cmd
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// trashDirName is the folder in the todo directory deleted notes are
+	// moved to.
+	trashDirName = ".trash"
+
+	// trashTimeLayout prefixes trashed file names so repeated deletes of
+	// the same note don't collide.
+	trashTimeLayout = "20060102-150405"
+
+	// trashNameLayout is trashTimeLayout with nanoseconds, which parsing
+	// with trashTimeLayout accepts, so deletes within a second still get
+	// names of their own.
+	trashNameLayout = trashTimeLayout + ".000000000"
+)
+
+// trashItem is a deleted note. The file in the trash is named
+// "<timestamp>_<escaped original path>".
+type trashItem struct {
+	name     string
+	original string
+	deleted  time.Time
+}
+
+func (i trashItem) Title() string { return i.original }
+func (i trashItem) Description() string {
+	return "Deleted: " + i.deleted.Format("Jan 02, 2006 3:04 PM")
+}
+func (i trashItem) FilterValue() string { return i.original }
+
+// parseTrashName recovers the deletion time and original path from the
+// name of a file in the trash.
+func parseTrashName(name string) (trashItem, bool) {
+	stamp, escaped, ok := strings.Cut(name, "_")
+	if !ok {
+		return trashItem{}, false
+	}
+	deleted, err := time.ParseInLocation(trashTimeLayout, stamp, time.Local)
+	if err != nil {
+		return trashItem{}, false
+	}
+	original, err := url.PathUnescape(escaped)
+	if err != nil {
+		return trashItem{}, false
+	}
+	return trashItem{name: name, original: original, deleted: deleted}, true
+}
+
+type trashKeyMap struct {
+	restore key.Binding
+	empty   key.Binding
+	back    key.Binding
+}
+
+func newTrashKeyMap() *trashKeyMap {
+	return &trashKeyMap{
+		restore: key.NewBinding(
+			key.WithKeys("r", "enter"),
+			key.WithHelp("r", "restore"),
+		),
+		empty: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "empty trash"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "back"),
+		),
+	}
+}
+
+func (m *model) trashDir() string {
+	return filepath.Join(m.todoDir, trashDirName)
+}
+
+// moveToTrash moves filename, relative to the todo directory, into the
+// trash and returns its name there.
+func (m *model) moveToTrash(filename string) (name string, err error) {
+	defer func() { logOp("trash", filename, err) }()
+	if err := os.MkdirAll(m.trashDir(), 0755); err != nil {
+		return "", err
+	}
+	// Never rename over an earlier deleted copy
+	now := time.Now()
+	for {
+		name = now.Format(trashNameLayout) + "_" + url.PathEscape(filename)
+		if _, err := os.Lstat(filepath.Join(m.trashDir(), name)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		now = now.Add(time.Nanosecond)
+	}
+	if err := os.Rename(filepath.Join(m.todoDir, filename), filepath.Join(m.trashDir(), name)); err != nil {
+		return "", err
+	}
+	m.index.forget(filename)
+	m.saveIndex()
+	return name, nil
+}
+
+// undoDeleteWindow is how long a delete from the todo list can be undone.
+const undoDeleteWindow = 5 * time.Second
+
+// deletedTodo is the last note deleted from the todo list, kept for a
+// short while so the delete can be undone.
+type deletedTodo struct {
+	id        int64
+	filename  string
+	trashName string
+	pinned    bool
+}
+
+type undoDeleteExpiredMsg struct {
+	id int64
+}
+
+// deleteTodo trashes filename, stashing it so undoDelete can bring it
+// back, and returns a command that expires the stash.
+func (m *model) deleteTodo(filename string) (tea.Cmd, error) {
+	trashName, err := m.moveToTrash(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stamp the stash so an older delete's timer can't expire it
+	id := time.Now().UnixNano()
+	m.lastDeleted = &deletedTodo{
+		id:        id,
+		filename:  filename,
+		trashName: trashName,
+		pinned:    m.pinned[filename],
+	}
+	delete(m.pinned, filename)
+	delete(m.marked, filename)
+
+	return tea.Tick(undoDeleteWindow, func(time.Time) tea.Msg {
+		return undoDeleteExpiredMsg{id: id}
+	}), nil
+}
+
+// undoDelete moves the last deleted note back out of the trash, keeping
+// its modification time.
+func (m *model) undoDelete() (string, error) {
+	d := m.lastDeleted
+	if d == nil {
+		return "", errors.New("nothing to undo")
+	}
+	m.lastDeleted = nil
+
+	if err := m.restoreFromTrash(trashItem{name: d.trashName, original: d.filename}); err != nil {
+		return d.filename, err
+	}
+	if d.pinned {
+		m.pinned[d.filename] = true
+	}
+	return d.filename, nil
+}
+
+// loadTrash lists the trashed notes, most recently deleted first.
+func (m *model) loadTrash() []list.Item {
+	files, err := os.ReadDir(m.trashDir())
+	if err != nil {
+		return []list.Item{}
+	}
+
+	var items []list.Item
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if it, ok := parseTrashName(file.Name()); ok {
+			items = append(items, it)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].(trashItem).deleted.After(items[j].(trashItem).deleted)
+	})
+	return items
+}
+
+// restoreFromTrash moves a trashed note back to where it was deleted
+// from, refusing to overwrite a note that has since taken its place.
+func (m *model) restoreFromTrash(it trashItem) (err error) {
+	defer func() { logOp("restore from trash", it.original, err) }()
+	target := filepath.Join(m.todoDir, it.original)
+	if _, err := os.Stat(target); err == nil {
+		return os.ErrExist
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Rename(filepath.Join(m.trashDir(), it.name), target)
+}
+
+// emptyTrash permanently deletes everything in the trash and returns how
+// many notes were removed, including those removed before a failure.
+func (m *model) emptyTrash() (int, error) {
+	items := m.loadTrash()
+	for i, it := range items {
+		err := os.Remove(filepath.Join(m.trashDir(), it.(trashItem).name))
+		logOp("delete from trash", it.(trashItem).original, err)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(items), nil
+}
+
+// openTrash loads the trash into a fresh list and switches to it.
+func (m *model) openTrash() {
+	m.trashList = list.New(m.loadTrash(), list.NewDefaultDelegate(), 0, 0)
+	m.trashList.Title = "Trash"
+	m.trashList.Styles.Title = todoTitleStyle
+	keys := m.trashKeys
+	m.trashList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{keys.restore, keys.empty}
+	}
+
+	h, v := docStyle.GetFrameSize()
+	m.trashList.SetSize(m.width-h, m.height-v)
+
+	m.state = trashView
+}
+
+// defaultTrashDays is how long deleted notes stay in the trash unless
+// $GOTODO_TRASH_DAYS says otherwise.
+const defaultTrashDays = 30
+
+// trashPurgedMsg reports the trash clear-out done at startup.
+type trashPurgedMsg struct {
+	count int
+	err   error
+}
+
+// trashRetentionFromEnv returns how long deleted notes are kept, from
+// $GOTODO_TRASH_DAYS. Zero, negative or "off" keeps them until the trash
+// is emptied by hand.
+func trashRetentionFromEnv() time.Duration {
+	setting := strings.TrimSpace(os.Getenv("GOTODO_TRASH_DAYS"))
+	if setting == "" {
+		return defaultTrashDays * 24 * time.Hour
+	}
+	days, err := strconv.Atoi(setting)
+	if err != nil || days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// purgeTrash permanently deletes the notes in dir's trash that were
+// deleted longer than retention before now, judging by the time in their
+// name or, failing that, when the file was last changed.
+func purgeTrash(dir string, retention time.Duration, now time.Time) (int, error) {
+	trashDir := filepath.Join(dir, trashDirName)
+	files, err := os.ReadDir(trashDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	purged := 0
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		it, ok := parseTrashName(file.Name())
+		if !ok {
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+			it = trashItem{name: file.Name(), original: file.Name(), deleted: info.ModTime()}
+		}
+		if now.Sub(it.deleted) < retention {
+			continue
+		}
+		err := os.Remove(filepath.Join(trashDir, it.name))
+		logOp("purge from trash", it.original, err)
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// startTrashPurge clears old notes out of the trash in the background,
+// unless purging is turned off.
+func (m *model) startTrashPurge() tea.Cmd {
+	retention := trashRetentionFromEnv()
+	if retention == 0 {
+		return nil
+	}
+	dir := m.todoDir
+	return func() tea.Msg {
+		count, err := purgeTrash(dir, retention, time.Now())
+		return trashPurgedMsg{count: count, err: err}
+	}
+}
+
+// trashPurgedStatus reports a startup purge on whichever list is showing.
+func (m *model) trashPurgedStatus(msg trashPurgedMsg) tea.Cmd {
+	var l *list.Model
+	switch m.state {
+	case listView:
+		l = &m.mainList
+	case todoListView:
+		l = &m.todoList
+	case trashView:
+		m.trashList.SetItems(m.loadTrash())
+		l = &m.trashList
+	default:
+		return nil
+	}
+	switch {
+	case msg.err != nil:
+		return m.listStatus(l, statusError, "Purging the trash failed: "+msg.err.Error())
+	case msg.count == 1:
+		return m.listStatus(l, statusNotice, "Purged 1 old note from the trash")
+	case msg.count > 1:
+		return m.listStatus(l, statusNotice, fmt.Sprintf("Purged %d old notes from the trash", msg.count))
+	}
+	return nil
+}
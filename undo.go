@@ -0,0 +1,92 @@
+package main
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// undoLimit bounds how many snapshots the editor keeps.
+	undoLimit = 50
+
+	// undoPause is how long typing has to stop before the next edit
+	// starts a new undo step.
+	undoPause = time.Second
+)
+
+type editorSnapshot struct {
+	value    string
+	row, col int
+}
+
+// snapshot captures value along with the current cursor position.
+func (m *model) snapshot(value string) editorSnapshot {
+	row, col := m.editorCursor()
+	return editorSnapshot{value: value, row: row, col: col}
+}
+
+// pushUndo records a state to return to and drops the redo branch, which
+// no longer applies once the buffer diverges.
+func (m *model) pushUndo(s editorSnapshot) {
+	m.undoStack = append(m.undoStack, s)
+	if len(m.undoStack) > undoLimit {
+		m.undoStack = m.undoStack[len(m.undoStack)-undoLimit:]
+	}
+	m.redoStack = nil
+}
+
+// recordTyping is called after a keypress changed the buffer from
+// before. Runs of typing are grouped into one undo step, broken by new
+// lines, word deletes and pauses.
+func (m *model) recordTyping(before editorSnapshot, msg tea.KeyMsg) {
+	now := time.Now()
+	wordDelete := key.Matches(msg, m.editor.KeyMap.DeleteWordBackward, m.editor.KeyMap.DeleteWordForward)
+	if len(m.undoStack) == 0 || msg.Type == tea.KeyEnter || wordDelete || now.Sub(m.lastEditAt) > undoPause {
+		m.pushUndo(before)
+	} else {
+		m.redoStack = nil
+	}
+	m.lastEditAt = now
+}
+
+// resetUndo forgets the history, e.g. when a different file is loaded.
+func (m *model) resetUndo() {
+	m.undoStack = nil
+	m.redoStack = nil
+}
+
+func (m *model) restoreSnapshot(s editorSnapshot) {
+	m.editor.SetValue(s.value)
+	m.setEditorCursor(s.row, s.col)
+	m.dirty = true
+	m.autosaved = false
+	m.counts = countText(s.value)
+	// The next keystroke starts a new step rather than extending this one
+	m.lastEditAt = time.Time{}
+}
+
+// undo steps back to the previous snapshot.
+func (m *model) undo() {
+	if len(m.undoStack) == 0 {
+		m.editorStatus = "Nothing to undo"
+		return
+	}
+	prev := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, m.snapshot(m.editor.Value()))
+	m.restoreSnapshot(prev)
+}
+
+// redo reapplies the most recently undone snapshot.
+func (m *model) redo() {
+	if len(m.redoStack) == 0 {
+		m.editorStatus = "Nothing to redo"
+		return
+	}
+	next := m.redoStack[len(m.redoStack)-1]
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack, m.snapshot(m.editor.Value()))
+	m.restoreSnapshot(next)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the todo directory has to stay quiet before
+// a burst of changes is reported as one.
+const watchDebounce = 250 * time.Millisecond
+
+// filesChangedMsg reports that notes changed on disk outside the app.
+type filesChangedMsg struct{}
+
+// todoWatcher watches the todo directory and its subfolders, coalescing
+// events into a single notification on changes.
+type todoWatcher struct {
+	watcher *fsnotify.Watcher
+	changes chan struct{}
+}
+
+// watchTodoDir starts watching dir. Hidden folders such as the trash are
+// left out, like they are in the list.
+func watchTodoDir(dir string) (*todoWatcher, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &todoWatcher{watcher: watcher, changes: make(chan struct{}, 1)}
+	if err := w.addTree(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *todoWatcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+func (w *todoWatcher) run() {
+	defer close(w.changes)
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if strings.HasPrefix(filepath.Base(event.Name), ".") {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				// Pick up new subfolders; errors just mean it wasn't one
+				w.addTree(event.Name)
+			}
+			debounce.Reset(watchDebounce)
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+				// A notification is already pending
+			}
+		}
+	}
+}
+
+// wait returns a command that delivers the next change notification.
+func (w *todoWatcher) wait() tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		if _, ok := <-w.changes; !ok {
+			return nil
+		}
+		return filesChangedMsg{}
+	}
+}
+
+// Close stops watching.
+func (w *todoWatcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.watcher.Close()
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultWorkspace names the todo directory from $GOTODO_DIR, which is
+// always a workspace whatever the config says.
+const defaultWorkspace = "default"
+
+// workspace is a named todo directory.
+type workspace struct {
+	name string
+	dir  string
+}
+
+// workspaceConfigPath returns where the workspaces are read from, normally
+// ~/.config/gotodo/workspaces.json. It maps names to directories:
+//
+//	{"work": "~/notes/work", "personal": "~/notes/home"}
+func workspaceConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gotodo", "workspaces.json"), nil
+}
+
+// expandHome replaces a leading ~ in dir with the home directory.
+func expandHome(dir, homeDir string) string {
+	if dir == "~" {
+		return homeDir
+	}
+	if len(dir) > 1 && dir[0] == '~' && os.IsPathSeparator(dir[1]) {
+		return filepath.Join(homeDir, dir[2:])
+	}
+	return dir
+}
+
+// loadWorkspaces returns the default workspace at defaultDir followed by
+// the configured ones in name order. A missing config file just means
+// there's only the default.
+func loadWorkspaces(path, defaultDir string) ([]workspace, error) {
+	workspaces := []workspace{{name: defaultWorkspace, dir: defaultDir}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return workspaces, nil
+	}
+	if err != nil {
+		return workspaces, err
+	}
+	var dirs map[string]string
+	if err := json.Unmarshal(data, &dirs); err != nil {
+		return workspaces, fmt.Errorf("%s: %w", path, err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return workspaces, err
+	}
+
+	var configured []workspace
+	for name, dir := range dirs {
+		if name == defaultWorkspace || dir == "" {
+			continue
+		}
+		dir, err := filepath.Abs(expandHome(dir, homeDir))
+		if err != nil {
+			return workspaces, fmt.Errorf("workspace %s: %w", name, err)
+		}
+		configured = append(configured, workspace{name: name, dir: dir})
+	}
+	sort.Slice(configured, func(i, j int) bool { return configured[i].name < configured[j].name })
+	return append(workspaces, configured...), nil
+}
+
+// findWorkspace returns the workspace called name, falling back to the
+// default one.
+func findWorkspace(workspaces []workspace, name string) workspace {
+	for _, ws := range workspaces {
+		if ws.name == name {
+			return ws
+		}
+	}
+	return workspaces[0]
+}
+
+// rememberWorkspace records name as the active workspace in the state
+// file of the default todo directory, which is where startup looks.
+func rememberWorkspace(defaultDir, name string) error {
+	state := loadState(defaultDir)
+	if state.Workspace == name {
+		return nil
+	}
+	state.Workspace = name
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(defaultDir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(defaultDir, stateFileName), data, 0644)
+}
+
+// mainMenuTitle names the active workspace unless it's the default.
+func (m model) mainMenuTitle() string {
+	if m.workspace == defaultWorkspace {
+		return "Todo App"
+	}
+	return "Todo App · " + m.workspace
+}
+
+func (m *model) workspaceItems() []list.Item {
+	items := make([]list.Item, 0, len(m.workspaces))
+	for _, ws := range m.workspaces {
+		desc := ws.dir
+		if ws.name == m.workspace {
+			desc += " (active)"
+		}
+		items = append(items, item{title: ws.name, desc: desc})
+	}
+	return items
+}
+
+// openWorkspaces lists the workspaces to switch between.
+func (m *model) openWorkspaces() {
+	m.workspaceList = list.New(m.workspaceItems(), list.NewDefaultDelegate(), 0, 0)
+	m.workspaceList.Title = "Workspaces"
+	m.workspaceList.Styles.Title = todoTitleStyle
+	for i, ws := range m.workspaces {
+		if ws.name == m.workspace {
+			m.workspaceList.Select(i)
+		}
+	}
+
+	h, v := docStyle.GetFrameSize()
+	m.workspaceList.SetSize(m.width-h, m.height-v)
+
+	m.state = workspaceView
+}
+
+// restoreState picks up the session saved in a todo directory.
+func (m *model) restoreState(state appState) {
+	m.sortMode = state.SortMode
+	m.lastSelected = state.LastSelected
+	m.recent = state.Recent
+	m.editorNoWrap = state.EditorNoWrap
+	m.labels = state.Labels
+	m.cursors = state.Cursors
+	m.pinned = make(map[string]bool)
+	for _, filename := range state.Pinned {
+		m.pinned[filename] = true
+	}
+}
+
+// switchWorkspace saves the session in the current workspace, moves
+// everything over to ws and opens its todo list.
+func (m *model) switchWorkspace(ws workspace) tea.Cmd {
+	if ws.name == m.workspace {
+		return m.listStatus(&m.workspaceList, statusInfo, "Already in "+ws.name)
+	}
+	if err := m.saveState(); err != nil {
+		return m.listStatus(&m.workspaceList, statusError, "Saving state failed: "+err.Error())
+	}
+	watcher, err := watchTodoDir(ws.dir)
+	if err != nil {
+		return m.listStatus(&m.workspaceList, statusError, "Can't open "+ws.name+": "+err.Error())
+	}
+	m.watcher.Close()
+	m.watcher = watcher
+
+	m.workspace = ws.name
+	m.todoDir = ws.dir
+	if m.index != nil {
+		m.index = openIndex(ws.dir)
+	}
+	m.restoreState(loadState(ws.dir))
+	if err := rememberWorkspace(m.workspaces[0].dir, ws.name); err != nil {
+		logOp("remember workspace", ws.name, err)
+	}
+
+	// Nothing from the old directory carries over
+	m.tagFilter, m.labelFilter = "", ""
+	m.completion = showAllNotes
+	m.marked = nil
+	m.lastDeleted = nil
+	m.peekCache = nil
+	m.previewOffsets = nil
+	m.loadErr = nil
+	m.mainList.Title = m.mainMenuTitle()
+
+	loadCmd := m.openTodoList()
+	statusCmd := m.listStatus(&m.todoList, statusNotice, "Switched to "+ws.name)
+	return tea.Batch(loadCmd, statusCmd, m.watcher.wait())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// zenTextWidth is the widest the editor gets in zen mode, so lines stay
+// a comfortable length to read on wide screens.
+const zenTextWidth = 80
+
+// zenMarginY is the number of blank rows kept above and below the editor
+// in zen mode.
+const zenMarginY = 2
+
+// zenStyle replaces the editor's border in zen mode.
+var zenStyle = lipgloss.NewStyle()
+
+// toggleZen hides or brings back everything around the editor text: the
+// title, header, help, borders and line numbers.
+func (m *model) toggleZen() {
+	m.zen = !m.zen
+	m.editor.ShowLineNumbers = !m.zen
+	if m.zen {
+		m.editor.FocusedStyle.Base = zenStyle
+		m.editor.BlurredStyle.Base = zenStyle
+	} else {
+		m.editor.FocusedStyle.Base = focusedBorderStyle
+		m.editor.BlurredStyle.Base = blurredBorderStyle
+	}
+	m.sizeEditor()
+	if m.splitActive() {
+		m.initSplitPreview()
+	}
+}
+
+// zenView centres the rendered editor on an otherwise empty screen, with
+// status, errors or the search prompt underneath when there are any.
+func (m model) zenView(editor, footer string) string {
+	if footer != "" {
+		editor = lipgloss.JoinVertical(lipgloss.Left, editor, "", footer)
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, editor)
+}